@@ -3,31 +3,46 @@ package application
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net"
 	"os"
-	"os/signal"
-	"syscall"
+	"sync"
 
 	"github.com/cyg-pd/go-core/httprouter"
 	"github.com/cyg-pd/go-core/msgrouter"
+	"github.com/cyg-pd/go-core/provider"
 	_ "github.com/cyg-pd/go-otelx/autoconf"
-	"golang.org/x/sync/errgroup"
 )
 
 type Application struct {
-	msgChan  chan struct{}
-	httpChan chan struct{}
-
 	msgRouter  *msgrouter.Router
 	httpRouter *httprouter.Server
+	providers  *provider.Registry
+
+	runnables    []runnable
+	shutdownPlan ShutdownPlan
+
+	signals        []os.Signal
+	signalHandlers map[os.Signal]func(ctx context.Context) error
 
 	beforeRunHooks      []func(ctx context.Context) error
 	beforeShutdownHooks []func(ctx context.Context) error
+
+	// mu guards running, set once started, and starting, claimed by Start
+	// so concurrent calls do not both boot the application.
+	mu       sync.Mutex
+	running  *running
+	starting bool
 }
 
 func (a *Application) HTTPRouter() *httprouter.Server   { return a.httpRouter }
 func (a *Application) MessageRouter() *msgrouter.Router { return a.msgRouter }
 
+// Providers returns the provider registry booted before the before-run hooks
+// and shut down after the before-shutdown hooks, see ShutdownPlan.
+func (a *Application) Providers() *provider.Registry { return a.providers }
+
 func (a *Application) AddBeforeRunHook(hook func(ctx context.Context) error) {
 	a.beforeRunHooks = append(a.beforeRunHooks, hook)
 }
@@ -36,113 +51,229 @@ func (a *Application) AddBeforeShutdownHook(hook func(ctx context.Context) error
 	a.beforeShutdownHooks = append(a.beforeShutdownHooks, hook)
 }
 
+// Run starts the application and blocks until a shutdown signal is received,
+// see WithSignals, or a runnable fails. It then stops the application
+// following the ShutdownPlan. The returned error joins every failure, each
+// wrapped in a StartupError or a ShutdownError.
 func (a *Application) Run(ctx context.Context) error {
-	for _, hook := range a.beforeRunHooks {
-		if err := hook(ctx); err != nil {
-			return err
-		}
+	if err := a.checkSignals(); err != nil {
+		return &StartupError{Err: err}
 	}
 
-	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	ctx, stop := a.notifyShutdown(ctx)
 	defer stop()
 
-	// Graceful shutdown
-	errGrp, ctx := errgroup.WithContext(ctx)
-	a.runEventBus(errGrp)
-	a.runHTTP(errGrp)
+	done := make(chan struct{})
+	defer close(done)
+	a.handleSignals(ctx, done)
 
-	// Shutdown application
-	errGrp.Go(func() error {
-		r := a.HTTPRouter()
-		ev := a.MessageRouter()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-		if r == nil && ev == nil {
-			return errors.New("core/application: http/event router is nil")
-		}
+	// Force shutdown
+	go a.forceShutdown(ctx, done)
 
-		<-ctx.Done()
-		defer func() {
-			if r == nil {
-				close(a.msgChan)
-				return
-			}
+	if err := a.Start(ctx); err != nil {
+		return err
+	}
 
-			close(a.httpChan)
-		}()
+	a.mu.Lock()
+	failed := a.running.failed
+	a.mu.Unlock()
+
+	// Graceful shutdown, once a signal is received or a runnable fails.
+	select {
+	case <-ctx.Done():
+	case <-failed:
+	}
+	cancel()
 
-		for _, hook := range a.beforeShutdownHooks {
-			if err := hook(ctx); err != nil {
-				return err
+	err := a.Stop(context.WithoutCancel(ctx))
+	slog.Info("core/application: server stopped")
+	flushTelemetry(context.WithoutCancel(ctx))
+
+	return err
+}
+
+// Start boots the providers, runs the before-run hooks and starts every
+// runnable, then returns once they are all ready: the HTTP server listens,
+// the message router runs and the other runnables implementing Readier are
+// ready. If a runnable fails or ctx is done first, the application is stopped
+// and the error returned.
+//
+// Unlike Run, Start does not handle signals, so tests can run the whole
+// application in process, on port 0, and call Stop when done.
+func (a *Application) Start(ctx context.Context) error {
+	if len(a.runnables) == 0 {
+		return &StartupError{Err: errors.New("no runnable to run")}
+	}
+
+	a.mu.Lock()
+	started := a.running != nil || a.starting
+	a.starting = !started
+	a.mu.Unlock()
+	if started {
+		return &StartupError{Err: errors.New("already started")}
+	}
+
+	if err := a.start(ctx); err != nil {
+		err = errors.Join(&StartupError{Err: err}, a.abort(ctx))
+		a.mu.Lock()
+		a.starting = false
+		a.mu.Unlock()
+		return err
+	}
+
+	rn := &running{failed: make(chan struct{})}
+	a.mu.Lock()
+	a.running, a.starting = rn, false
+	a.mu.Unlock()
+
+	for _, r := range a.runnables {
+		// Runnables are stopped by the drain phase, not by ctx.
+		runCtx := context.WithoutCancel(ctx)
+		rn.wg.Add(1)
+		go func() {
+			defer rn.wg.Done()
+			if err := r.Run(runCtx); err != nil {
+				rn.fail(&StartupError{Err: fmt.Errorf("%s: %w", r.name, err)})
 			}
+		}()
+	}
+
+	for _, r := range a.runnables {
+		select {
+		case <-ready(r.Runnable):
+		case <-rn.failed:
+			return a.Stop(context.WithoutCancel(ctx))
+		case <-ctx.Done():
+			return errors.Join(&StartupError{Err: ctx.Err()}, a.Stop(context.WithoutCancel(ctx)))
 		}
+	}
 
+	return nil
+}
+
+// Stop shuts the application down following the ShutdownPlan, then waits for
+// the runnables to return until ctx is done. It returns every failure since
+// Start, each wrapped in a StartupError or a ShutdownError. Stop does nothing
+// when the application is not started; later calls return the result of the
+// first one.
+func (a *Application) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	rn := a.running
+	a.mu.Unlock()
+
+	if rn == nil {
 		return nil
+	}
+
+	rn.stopOnce.Do(func() {
+		rn.collect(wrapShutdown(a.shutdown(ctx)))
+
+		stopped := make(chan struct{})
+		go func() {
+			rn.wg.Wait()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			rn.collect(&ShutdownError{Err: fmt.Errorf("waiting for runnables: %w", ctx.Err())})
+		}
+
+		rn.mu.Lock()
+		rn.stopErr = errors.Join(rn.errs...)
+		rn.mu.Unlock()
 	})
 
-	// Force shutdown
-	go a.forceShutdown(ctx)
+	return rn.stopErr
+}
 
-	if err := errGrp.Wait(); err != nil {
-		slog.Error("core/application: " + err.Error())
+// HTTPAddr returns the address the HTTP server listens on, with port 0
+// resolved, or nil until it listens.
+func (a *Application) HTTPAddr() net.Addr {
+	if a.httpRouter == nil {
+		return nil
 	}
+	return a.httpRouter.Addr()
+}
 
-	slog.Info("core/application: server stopped")
+// running tracks the runnables started by Start.
+type running struct {
+	wg       sync.WaitGroup
+	failed   chan struct{}
+	failOnce sync.Once
 
-	return nil
+	mu   sync.Mutex
+	errs []error
+
+	stopOnce sync.Once
+	stopErr  error
 }
 
-func (a *Application) runHTTP(errGrp *errgroup.Group) {
-	r := a.HTTPRouter()
-	if r == nil {
+func (rn *running) collect(err error) {
+	if err == nil {
 		return
 	}
 
-	// Start http server
-	errGrp.Go(func() error { return r.Run() })
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	rn.errs = append(rn.errs, err)
+}
 
-	// Stop http server
-	errGrp.Go(func() error {
-		<-a.httpChan
-		defer close(a.msgChan)
-		return r.Shutdown(context.Background())
-	})
+func (rn *running) fail(err error) {
+	rn.collect(err)
+	rn.failOnce.Do(func() { close(rn.failed) })
 }
 
-func (a *Application) runEventBus(errGrp *errgroup.Group) {
-	ev := a.MessageRouter()
-	if ev == nil {
-		return
+// start boots the providers and runs the before-run hooks.
+func (a *Application) start(ctx context.Context) error {
+	if err := a.providers.Boot(ctx); err != nil {
+		return err
 	}
 
-	// Start event
-	errGrp.Go(func() error { return ev.Run(context.Background()) })
+	for _, hook := range a.beforeRunHooks {
+		if err := hook(ctx); err != nil {
+			return err
+		}
+	}
 
-	// Stop event
-	errGrp.Go(func() error {
-		<-a.msgChan
-		return ev.Shutdown(context.Background())
-	})
+	return nil
 }
 
-func (a *Application) forceShutdown(ctx context.Context) {
-	slog.Info("core/application: press Ctrl+C to stop server")
-	<-ctx.Done()
+// abort shuts down the providers booted by a failed start.
+func (a *Application) abort(ctx context.Context) error {
+	ctx, cancel := withTimeout(context.WithoutCancel(ctx), a.shutdownPlan.Providers)
+	defer cancel()
 
-	fCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	slog.Info("core/application: shutting down gracefully, press Ctrl+C again to force stop")
-	<-fCtx.Done()
+	return wrapShutdown(a.providers.Shutdown(ctx))
+}
 
-	slog.Info("core/application: shutting down immediately")
-	stop()
-	os.Exit(1)
+func wrapShutdown(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ShutdownError{Err: err}
 }
 
-func New(httpRouter *httprouter.Server, msgRouter *msgrouter.Router) *Application {
+func New(httpRouter *httprouter.Server, msgRouter *msgrouter.Router, opts ...option) *Application {
 	app := &Application{
-		msgChan:    make(chan struct{}),
-		httpChan:   make(chan struct{}),
-		msgRouter:  msgRouter,
-		httpRouter: httpRouter,
+		msgRouter:    msgRouter,
+		httpRouter:   httpRouter,
+		providers:    provider.NewRegistry(),
+		shutdownPlan: DefaultShutdownPlan(),
+		signals:      defaultSignals,
+	}
+	if msgRouter != nil {
+		app.AddRunnable("message", msgRouter)
+	}
+	if httpRouter != nil {
+		app.AddRunnable("http", httpServer{httpRouter})
+	}
+	for _, opt := range opts {
+		opt.apply(app)
 	}
 	return app
 }
@@ -0,0 +1,89 @@
+package application
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cyg-pd/go-core/httprouter"
+	"github.com/cyg-pd/go-core/msgrouter"
+	"github.com/cyg-pd/go-core/provider"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartStop(t *testing.T) {
+	v := viper.New()
+	v.Set("http.host", "127.0.0.1")
+	v.Set("http.port", 0)
+
+	httpRouter := httprouter.New(v)
+	httpRouter.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+	msgRouter := msgrouter.New()
+
+	plan := DefaultShutdownPlan()
+	plan.PreStop = 0
+	app := New(httpRouter, msgRouter, WithShutdownPlan(plan))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	is := assert.New(t)
+	is.Nil(app.HTTPAddr())
+	is.NoError(app.Start(ctx))
+	is.True(msgRouter.IsRunning())
+
+	addr := app.HTTPAddr()
+	is.NotNil(addr)
+
+	res, err := http.Get("http://" + addr.String() + "/ping")
+	if is.NoError(err) {
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		is.Equal("pong", string(body))
+	}
+
+	is.Error(app.Start(ctx))
+	is.NoError(app.Stop(ctx))
+	is.NoError(app.Stop(ctx))
+	is.True(msgRouter.IsClosed())
+}
+
+// countingProvider counts its boots, each blocking until release is closed.
+type countingProvider struct {
+	provider.NoopProvider
+	boots   atomic.Int32
+	release chan struct{}
+}
+
+func (c *countingProvider) Boot() error {
+	c.boots.Add(1)
+	<-c.release
+	return nil
+}
+
+func TestConcurrentStart(t *testing.T) {
+	p := &countingProvider{release: make(chan struct{})}
+	app := New(nil, nil, WithShutdownPlan(ShutdownPlan{}))
+	app.Providers().Register(p)
+	r := &blockingRunnable{started: make(chan struct{}), release: make(chan struct{})}
+	app.AddRunnable("worker", r)
+
+	ctx := context.Background()
+	errs := make(chan error, 2)
+	go func() { errs <- app.Start(ctx) }()
+	go func() { errs <- app.Start(ctx) }()
+
+	is := assert.New(t)
+	is.ErrorContains(<-errs, "already started")
+	close(p.release)
+	is.NoError(<-errs)
+	is.Equal(int32(1), p.boots.Load())
+
+	close(r.release)
+	is.NoError(app.Stop(ctx))
+}
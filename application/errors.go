@@ -0,0 +1,48 @@
+package application
+
+import "errors"
+
+// Exit codes returned by ExitCode.
+const (
+	ExitCodeOK       = 0
+	ExitCodeFailure  = 1
+	ExitCodeStartup  = 3
+	ExitCodeShutdown = 4
+)
+
+// StartupError reports that the application failed to start or to keep
+// serving: a provider failed to boot, a before-run hook failed or a server
+// stopped with an error, such as a port already in use.
+type StartupError struct{ Err error }
+
+func (e *StartupError) Error() string { return "core/application: startup: " + e.Err.Error() }
+func (e *StartupError) Unwrap() error { return e.Err }
+
+// ShutdownError reports that the application did not shut down cleanly: a
+// shutdown phase, a before-shutdown hook or a provider failed or timed out.
+type ShutdownError struct{ Err error }
+
+func (e *ShutdownError) Error() string { return "core/application: shutdown: " + e.Err.Error() }
+func (e *ShutdownError) Unwrap() error { return e.Err }
+
+// ExitCode maps an error returned by Application.Run to a process exit code.
+// Startup failures take precedence over shutdown failures.
+//
+//	if err := app.Run(ctx); err != nil {
+//		os.Exit(application.ExitCode(err))
+//	}
+func ExitCode(err error) int {
+	var startup *StartupError
+	var shutdown *ShutdownError
+
+	switch {
+	case err == nil:
+		return ExitCodeOK
+	case errors.As(err, &startup):
+		return ExitCodeStartup
+	case errors.As(err, &shutdown):
+		return ExitCodeShutdown
+	default:
+		return ExitCodeFailure
+	}
+}
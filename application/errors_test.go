@@ -0,0 +1,22 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	startup := &StartupError{Err: errors.New("listen tcp :80: bind: address already in use")}
+	shutdown := &ShutdownError{Err: errors.New("core/provider: shutdown db: timed out")}
+
+	is := assert.New(t)
+	is.Equal(ExitCodeOK, ExitCode(nil))
+	is.Equal(ExitCodeFailure, ExitCode(errors.New("unknown")))
+	is.Equal(ExitCodeStartup, ExitCode(startup))
+	is.Equal(ExitCodeShutdown, ExitCode(shutdown))
+	is.Equal(ExitCodeStartup, ExitCode(errors.Join(shutdown, startup)))
+	is.Equal(ExitCodeShutdown, ExitCode(fmt.Errorf("main: %w", shutdown)))
+}
@@ -0,0 +1,67 @@
+package application
+
+import (
+	"context"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/cyg-pd/go-core/provider"
+	"github.com/spf13/viper"
+)
+
+type option interface{ apply(*Application) }
+type optionFunc func(*Application)
+
+func (fn optionFunc) apply(a *Application) { fn(a) }
+
+// WithProviders sets the provider registry owned by the application.
+func WithProviders(r *provider.Registry) option {
+	return optionFunc(func(a *Application) {
+		a.providers = r
+	})
+}
+
+// WithConfig reads the application settings, such as the app.shutdown.* keys
+// of the ShutdownPlan, from v.
+func WithConfig(v *viper.Viper) option {
+	return optionFunc(func(a *Application) {
+		a.shutdownPlan = ShutdownPlanFromConfig(v)
+	})
+}
+
+// WithShutdownPlan sets the timeouts of the shutdown phases.
+func WithShutdownPlan(plan ShutdownPlan) option {
+	return optionFunc(func(a *Application) {
+		a.shutdownPlan = plan
+	})
+}
+
+// WithShutdownTimeout bounds the whole shutdown, see ShutdownPlan.Timeout.
+func WithShutdownTimeout(timeout time.Duration) option {
+	return optionFunc(func(a *Application) {
+		a.shutdownPlan.Timeout = timeout
+	})
+}
+
+// WithSignals sets the signals starting the graceful shutdown. A second one
+// received during the shutdown forces the exit. Defaults to SIGINT and SIGTERM.
+// Without signals, Run handles none and stops once ctx is done or a runnable
+// fails.
+func WithSignals(sigs ...os.Signal) option {
+	return optionFunc(func(a *Application) {
+		a.signals = slices.Clone(sigs)
+	})
+}
+
+// WithSignalHandler calls handler each time sig is received while running,
+// for instance to reload the configuration on SIGHUP. sig must not be one of
+// the shutdown signals, or Run fails with ErrSignalConflict.
+func WithSignalHandler(sig os.Signal, handler func(ctx context.Context) error) option {
+	return optionFunc(func(a *Application) {
+		if a.signalHandlers == nil {
+			a.signalHandlers = map[os.Signal]func(ctx context.Context) error{}
+		}
+		a.signalHandlers[sig] = handler
+	})
+}
@@ -0,0 +1,85 @@
+package application
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cyg-pd/go-core/httprouter"
+	"github.com/cyg-pd/go-core/msgrouter"
+)
+
+// Runnable is a component served by the application, such as a gRPC server,
+// a cron scheduler or a background worker. Run blocks until Shutdown is
+// called; Shutdown stops the component and waits for its work in flight
+// until ctx is done.
+type Runnable interface {
+	Run(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
+
+// StopAccepter is implemented by runnables that can stop taking new work
+// ahead of Shutdown, such as servers closing their listener.
+type StopAccepter interface {
+	StopAccepting() error
+}
+
+// Readier is implemented by runnables that take time to be ready to serve,
+// such as servers binding their listener. Start waits for Ready to be closed.
+type Readier interface {
+	Ready() <-chan struct{}
+}
+
+type runnable struct {
+	name string
+	Runnable
+}
+
+// AddRunnable registers a component started by Run alongside the others.
+// Runnables are shut down in reverse order of registration, after the ones
+// added later; the built-in HTTP server and message router are registered
+// by New, so they are shut down last, HTTP first.
+func (a *Application) AddRunnable(name string, r Runnable) {
+	a.runnables = append(a.runnables, runnable{name: name, Runnable: r})
+}
+
+// httpServer runs an httprouter.Server as a Runnable.
+type httpServer struct{ *httprouter.Server }
+
+func (s httpServer) Run(context.Context) error { return s.Server.Run() }
+
+// The HTTP server skips stopping when it is not running: it already failed
+// to start and its error has been reported.
+func (s httpServer) StopAccepting() error { return ignoreNotRunning(s.Server.StopAccepting()) }
+func (s httpServer) Shutdown(ctx context.Context) error {
+	return ignoreNotRunning(s.Server.Shutdown(ctx))
+}
+
+func (s httpServer) Ready() <-chan struct{} { return s.Server.Listening() }
+
+func ignoreNotRunning(err error) error {
+	if errors.Is(err, httprouter.ErrServerNotRunning) {
+		return nil
+	}
+	return err
+}
+
+// ready returns a channel closed once r is ready to serve.
+func ready(r Runnable) <-chan struct{} {
+	switch r := r.(type) {
+	case Readier:
+		return r.Ready()
+	case *msgrouter.Router:
+		return r.Running()
+	}
+
+	c := make(chan struct{})
+	close(c)
+	return c
+}
+
+var (
+	_ Runnable     = httpServer{}
+	_ Readier      = httpServer{}
+	_ StopAccepter = httpServer{}
+	_ Runnable     = (*msgrouter.Router)(nil)
+)
@@ -0,0 +1,64 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRunnable struct {
+	name string
+	err  error
+	log  *[]string
+	mu   *sync.Mutex
+
+	stop chan struct{}
+}
+
+func (m *mockRunnable) Run(context.Context) error {
+	if m.err != nil {
+		return m.err
+	}
+	<-m.stop
+	return nil
+}
+
+func (m *mockRunnable) Shutdown(context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	*m.log = append(*m.log, m.name)
+	if m.err == nil {
+		close(m.stop)
+	}
+	return nil
+}
+
+func TestRunnableShutdownOrder(t *testing.T) {
+	var mu sync.Mutex
+	log := []string{}
+
+	app := New(nil, nil)
+	for _, name := range []string{"cron", "grpc"} {
+		app.AddRunnable(name, &mockRunnable{name: name, log: &log, mu: &mu, stop: make(chan struct{})})
+	}
+	failure := errors.New("listen tcp :9090: bind: address already in use")
+	app.AddRunnable("metrics", &mockRunnable{name: "metrics", err: failure, log: &log, mu: &mu})
+
+	err := app.Run(context.Background())
+
+	is := assert.New(t)
+	is.ErrorIs(err, failure)
+	is.Equal(ExitCodeStartup, ExitCode(err))
+	is.Equal([]string{"metrics", "grpc", "cron"}, log)
+}
+
+func TestRunWithoutRunnable(t *testing.T) {
+	err := New(nil, nil).Run(context.Background())
+
+	is := assert.New(t)
+	is.Equal(ExitCodeStartup, ExitCode(err))
+}
@@ -0,0 +1,175 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/cyg-pd/go-core/msgrouter"
+	"github.com/spf13/viper"
+)
+
+// ShutdownPlan holds the timeouts of the shutdown phases, run in order:
+//
+//  1. pre-stop: run provider.PreStopper providers and wait PreStopDelay,
+//     giving load balancers time to stop routing traffic to the instance
+//  2. stop accepting: close the HTTP listener and the listeners of the
+//     other runnables implementing StopAccepter
+//  3. drain: shut down each runnable in turn, in reverse order of
+//     registration, waiting for its work in flight; the HTTP server is
+//     bounded by HTTPDrain, the message router by MessageDrain and the other
+//     runnables by Runnables
+//  4. providers: run the before-shutdown hooks, then shut down the providers
+//
+// Timeout bounds the whole shutdown: once exceeded, the process flushes its
+// telemetry and exits with status 1. Zero means no limit, for Timeout as for
+// the phase timeouts; the providers then keep the deadlines of the registry,
+// see provider.WithTimeout.
+type ShutdownPlan struct {
+	Timeout       time.Duration
+	PreStopDelay  time.Duration
+	PreStop       time.Duration
+	StopAccepting time.Duration
+	HTTPDrain     time.Duration
+	MessageDrain  time.Duration
+	Runnables     time.Duration
+	Providers     time.Duration
+}
+
+// DefaultShutdownPlan returns the plan used when none is configured.
+func DefaultShutdownPlan() ShutdownPlan {
+	return ShutdownPlan{
+		PreStop:       10 * time.Second,
+		StopAccepting: 5 * time.Second,
+		HTTPDrain:     30 * time.Second,
+		MessageDrain:  30 * time.Second,
+		Runnables:     30 * time.Second,
+		Providers:     30 * time.Second,
+	}
+}
+
+// ShutdownPlanFromConfig reads the plan from the app.shutdown.* keys of v,
+// such as app.shutdown.http.drain.timeout, keeping the defaults for the keys
+// that are not set. A key set to 0 removes the limit.
+func ShutdownPlanFromConfig(v *viper.Viper) ShutdownPlan {
+	plan := DefaultShutdownPlan()
+	set := func(key string, d *time.Duration) {
+		if v.IsSet(key) {
+			*d = v.GetDuration(key)
+		}
+	}
+
+	set("app.shutdown.timeout", &plan.Timeout)
+	set("app.shutdown.pre.stop.delay", &plan.PreStopDelay)
+	set("app.shutdown.pre.stop.timeout", &plan.PreStop)
+	set("app.shutdown.stop.accepting.timeout", &plan.StopAccepting)
+	set("app.shutdown.http.drain.timeout", &plan.HTTPDrain)
+	set("app.shutdown.message.drain.timeout", &plan.MessageDrain)
+	set("app.shutdown.runnables.timeout", &plan.Runnables)
+	set("app.shutdown.providers.timeout", &plan.Providers)
+
+	return plan
+}
+
+type shutdownPhase struct {
+	name    string
+	timeout time.Duration
+	run     func(ctx context.Context) error
+}
+
+func (a *Application) shutdownPhases() []shutdownPhase {
+	plan := a.shutdownPlan
+	phases := []shutdownPhase{
+		{"pre-stop", preStopTimeout(plan), a.preStop},
+		{"stop accepting", plan.StopAccepting, a.stopAccepting},
+	}
+
+	for _, r := range slices.Backward(a.runnables) {
+		timeout := plan.Runnables
+		switch r.Runnable.(type) {
+		case httpServer:
+			timeout = plan.HTTPDrain
+		case *msgrouter.Router:
+			timeout = plan.MessageDrain
+		}
+
+		phases = append(phases, shutdownPhase{r.name + " drain", timeout, r.Shutdown})
+	}
+
+	return append(phases, shutdownPhase{"hooks and providers", plan.Providers, a.shutdownHooksProviders})
+}
+
+// shutdown runs every phase, even after one of them failed.
+func (a *Application) shutdown(ctx context.Context) error {
+	errs := []error{}
+	for _, phase := range a.shutdownPhases() {
+		start := time.Now()
+
+		pctx, cancel := withTimeout(ctx, phase.timeout)
+		err := phase.run(pctx)
+		cancel()
+
+		attrs := []any{
+			slog.String("phase", phase.name),
+			slog.Int64("latency", time.Since(start).Milliseconds()),
+		}
+		if err != nil {
+			slog.Error("core/application: shutdown "+phase.name+" failed: "+err.Error(), attrs...)
+			errs = append(errs, err)
+		} else {
+			slog.Info("core/application: shutdown "+phase.name+" done", attrs...)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// preStopTimeout leaves room for PreStopDelay, unless PreStop is unlimited.
+func preStopTimeout(plan ShutdownPlan) time.Duration {
+	if plan.PreStop <= 0 {
+		return 0
+	}
+	return max(plan.PreStop, plan.PreStopDelay)
+}
+
+// withTimeout bounds ctx by timeout, zero meaning no limit.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (a *Application) preStop(ctx context.Context) error {
+	delay := time.After(a.shutdownPlan.PreStopDelay)
+	err := a.providers.PreStop(ctx)
+
+	select {
+	case <-delay:
+	case <-ctx.Done():
+	}
+
+	return err
+}
+
+func (a *Application) stopAccepting(context.Context) error {
+	errs := []error{}
+	for _, r := range a.runnables {
+		if s, ok := r.Runnable.(StopAccepter); ok {
+			errs = append(errs, s.StopAccepting())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *Application) shutdownHooksProviders(ctx context.Context) error {
+	errs := []error{}
+	for _, hook := range a.beforeShutdownHooks {
+		errs = append(errs, hook(ctx))
+	}
+
+	errs = append(errs, a.providers.Shutdown(ctx))
+	return errors.Join(errs...)
+}
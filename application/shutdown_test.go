@@ -0,0 +1,202 @@
+package application
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyg-pd/go-core/httprouter"
+	"github.com/cyg-pd/go-core/msgrouter"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// phaseRecorder records the calls of a shutdown with the time left to their
+// deadline, zero when unlimited.
+type phaseRecorder struct {
+	mu    sync.Mutex
+	calls []string
+	left  map[string]time.Duration
+}
+
+func (r *phaseRecorder) record(ctx context.Context, call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, call)
+	if r.left == nil {
+		r.left = map[string]time.Duration{}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		r.left[call] = time.Until(deadline)
+	}
+}
+
+type recordingRunnable struct {
+	name string
+	rec  *phaseRecorder
+}
+
+func (r *recordingRunnable) Run(context.Context) error { return nil }
+func (r *recordingRunnable) Shutdown(ctx context.Context) error {
+	r.rec.record(ctx, r.name+" drain")
+	return nil
+}
+func (r *recordingRunnable) StopAccepting() error {
+	r.rec.record(context.Background(), r.name+" stop accepting")
+	return nil
+}
+
+type recordingProvider struct {
+	name string
+	rec  *phaseRecorder
+}
+
+func (p *recordingProvider) Name() string               { return p.name }
+func (p *recordingProvider) Boot(context.Context) error { return nil }
+func (p *recordingProvider) PreStop(ctx context.Context) error {
+	p.rec.record(ctx, p.name+" pre-stop")
+	return nil
+}
+func (p *recordingProvider) Shutdown(ctx context.Context) error {
+	p.rec.record(ctx, p.name+" shutdown")
+	return nil
+}
+
+func shutdownConfig() *viper.Viper {
+	v := viper.New()
+	v.Set("app.shutdown.timeout", "2m")
+	v.Set("app.shutdown.pre.stop.delay", "10ms")
+	v.Set("app.shutdown.pre.stop.timeout", "1s")
+	v.Set("app.shutdown.stop.accepting.timeout", "2s")
+	v.Set("app.shutdown.http.drain.timeout", "3s")
+	v.Set("app.shutdown.message.drain.timeout", "4s")
+	v.Set("app.shutdown.runnables.timeout", "5s")
+	v.Set("app.shutdown.providers.timeout", "6s")
+	return v
+}
+
+func TestShutdownPlanFromConfig(t *testing.T) {
+	is := assert.New(t)
+	is.Equal(ShutdownPlan{
+		Timeout:       2 * time.Minute,
+		PreStopDelay:  10 * time.Millisecond,
+		PreStop:       time.Second,
+		StopAccepting: 2 * time.Second,
+		HTTPDrain:     3 * time.Second,
+		MessageDrain:  4 * time.Second,
+		Runnables:     5 * time.Second,
+		Providers:     6 * time.Second,
+	}, ShutdownPlanFromConfig(shutdownConfig()))
+
+	v := viper.New()
+	v.Set("app.shutdown.http.drain.timeout", "1s")
+	plan := DefaultShutdownPlan()
+	plan.HTTPDrain = time.Second
+	is.Equal(plan, ShutdownPlanFromConfig(v))
+
+	v.Set("app.shutdown.providers.timeout", 0)
+	plan.Providers = 0
+	is.Equal(plan, ShutdownPlanFromConfig(v))
+}
+
+func TestShutdownPhases(t *testing.T) {
+	app := New(httprouter.New(viper.New()), msgrouter.New(), WithConfig(shutdownConfig()))
+	app.AddRunnable("grpc", &recordingRunnable{})
+
+	phases := map[string]time.Duration{}
+	names := []string{}
+	for _, p := range app.shutdownPhases() {
+		names = append(names, p.name)
+		phases[p.name] = p.timeout
+	}
+
+	is := assert.New(t)
+	is.Equal([]string{"pre-stop", "stop accepting", "grpc drain", "http drain", "message drain", "hooks and providers"}, names)
+	is.Equal(map[string]time.Duration{
+		"pre-stop":            time.Second,
+		"stop accepting":      2 * time.Second,
+		"grpc drain":          5 * time.Second,
+		"http drain":          3 * time.Second,
+		"message drain":       4 * time.Second,
+		"hooks and providers": 6 * time.Second,
+	}, phases)
+}
+
+func TestShutdownOrder(t *testing.T) {
+	rec := &phaseRecorder{}
+	app := New(nil, nil, WithConfig(shutdownConfig()))
+	app.AddRunnable("grpc", &recordingRunnable{name: "grpc", rec: rec})
+	app.AddRunnable("cron", &recordingRunnable{name: "cron", rec: rec})
+	app.AddBeforeShutdownHook(func(ctx context.Context) error {
+		rec.record(ctx, "hook")
+		return nil
+	})
+	app.Providers().RegisterContext(&recordingProvider{name: "db", rec: rec})
+	app.Providers().RegisterContext(&recordingProvider{name: "cache", rec: rec})
+
+	is := assert.New(t)
+	is.NoError(app.Providers().Boot(context.Background()))
+	is.NoError(app.shutdown(context.Background()))
+
+	is.Equal([]string{
+		"cache pre-stop", "db pre-stop",
+		"grpc stop accepting", "cron stop accepting",
+		"cron drain", "grpc drain",
+		"hook", "cache shutdown", "db shutdown",
+	}, rec.calls)
+
+	within := func(call string, timeout time.Duration) {
+		is.InDelta(timeout, rec.left[call], float64(500*time.Millisecond), call)
+	}
+	within("cache pre-stop", time.Second)
+	within("cron drain", 5*time.Second)
+	within("hook", 6*time.Second)
+}
+
+func TestShutdownZeroTimeouts(t *testing.T) {
+	rec := &phaseRecorder{}
+	app := New(nil, nil, WithShutdownPlan(ShutdownPlan{HTTPDrain: 10 * time.Second}))
+	app.AddRunnable("grpc", &recordingRunnable{name: "grpc", rec: rec})
+	app.Providers().RegisterContext(&recordingProvider{name: "db", rec: rec})
+
+	is := assert.New(t)
+	is.NoError(app.Providers().Boot(context.Background()))
+	is.NoError(app.shutdown(context.Background()))
+
+	is.Equal([]string{"db pre-stop", "grpc stop accepting", "grpc drain", "db shutdown"}, rec.calls)
+	is.NotContains(rec.left, "grpc drain")
+}
+
+// probingRunnable probes the HTTP server when drained, after it stopped
+// accepting and before it is drained itself.
+type probingRunnable struct {
+	addr func() string
+	err  error
+}
+
+func (p *probingRunnable) Run(context.Context) error { return nil }
+func (p *probingRunnable) Shutdown(context.Context) error {
+	_, p.err = (&http.Client{Timeout: time.Second}).Get("http://" + p.addr() + "/")
+	return nil
+}
+
+func TestHTTPStopAcceptingBeforeDrain(t *testing.T) {
+	v := viper.New()
+	v.Set("http.host", "127.0.0.1")
+	v.Set("http.port", 0)
+
+	app := New(httprouter.New(v), nil, WithShutdownPlan(ShutdownPlan{}))
+	probe := &probingRunnable{addr: func() string { return app.HTTPAddr().String() }}
+	app.AddRunnable("probe", probe)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	is := assert.New(t)
+	is.NoError(app.Start(ctx))
+	is.NoError(app.Stop(ctx))
+	is.Error(probe.err)
+}
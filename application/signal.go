@@ -0,0 +1,147 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/log/global"
+)
+
+// exit is replaced in tests.
+var exit = os.Exit
+
+var defaultSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+
+// ErrSignalConflict is returned by Run when a signal passed to
+// WithSignalHandler is also a shutdown signal, see WithSignals.
+var ErrSignalConflict = errors.New("core/application: signal both handled and shutting down")
+
+// checkSignals rejects the handled signals that are also shutdown signals.
+func (a *Application) checkSignals() error {
+	for sig := range a.signalHandlers {
+		if slices.Contains(a.signals, sig) {
+			return fmt.Errorf("%w: %s", ErrSignalConflict, sig)
+		}
+	}
+	return nil
+}
+
+// notifyShutdown returns a context done once a shutdown signal is received.
+// An empty list of signals handles none, where signal.NotifyContext would
+// relay them all.
+func (a *Application) notifyShutdown(ctx context.Context) (context.Context, context.CancelFunc) {
+	if len(a.signals) == 0 {
+		return context.WithCancel(ctx)
+	}
+	return signal.NotifyContext(ctx, a.signals...)
+}
+
+// handleSignals runs the handlers registered with WithSignalHandler until
+// done is closed.
+func (a *Application) handleSignals(ctx context.Context, done <-chan struct{}) {
+	if len(a.signalHandlers) == 0 {
+		return
+	}
+
+	sigs := make([]os.Signal, 0, len(a.signalHandlers))
+	for sig := range a.signalHandlers {
+		sigs = append(sigs, sig)
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		defer signal.Stop(ch)
+
+		for {
+			select {
+			case sig := <-ch:
+				slog.Info("core/application: received " + sig.String())
+				if err := a.signalHandlers[sig](ctx); err != nil {
+					slog.Error("core/application: " + sig.String() + " handler: " + err.Error())
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// forceShutdown exits the process when a second signal is received or the
+// shutdown outlasts ShutdownPlan.Timeout, flushing the telemetry first.
+func (a *Application) forceShutdown(ctx context.Context, done <-chan struct{}) {
+	if isTerminal() {
+		slog.Info("core/application: press Ctrl+C to stop server")
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-done:
+		return
+	}
+
+	sig := make(chan os.Signal, 1)
+	if len(a.signals) > 0 {
+		signal.Notify(sig, a.signals...)
+		defer signal.Stop(sig)
+	}
+
+	if isTerminal() {
+		slog.Info("core/application: shutting down gracefully, press Ctrl+C again to force stop")
+	}
+
+	var timeout <-chan time.Time
+	if t := a.shutdownPlan.Timeout; t > 0 {
+		timeout = time.After(t)
+	}
+
+	select {
+	case s := <-sig:
+		slog.Warn("core/application: received " + s.String() + " again, shutting down immediately")
+	case <-timeout:
+		slog.Error("core/application: shutdown exceeded " + a.shutdownPlan.Timeout.String() + ", shutting down immediately")
+	case <-done:
+		return
+	}
+
+	flushTelemetry(context.Background())
+	exit(1)
+}
+
+// flushTelemetry flushes the global OpenTelemetry trace, metric and log
+// providers, so what was recorded up to a forced exit is not lost.
+func flushTelemetry(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	providers := map[string]any{
+		"trace":  otel.GetTracerProvider(),
+		"metric": otel.GetMeterProvider(),
+		"log":    global.GetLoggerProvider(),
+	}
+
+	for name, p := range providers {
+		f, ok := p.(interface{ ForceFlush(context.Context) error })
+		if !ok {
+			continue
+		}
+
+		if err := f.ForceFlush(ctx); err != nil {
+			slog.Error("core/application: flush " + name + " telemetry: " + err.Error())
+		}
+	}
+}
+
+func isTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,117 @@
+package application
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type blockingRunnable struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingRunnable) Run(context.Context) error {
+	close(b.started)
+	<-b.release
+	return nil
+}
+
+func (b *blockingRunnable) Shutdown(context.Context) error {
+	<-b.release
+	return nil
+}
+
+func TestSignalHandler(t *testing.T) {
+	r := &blockingRunnable{started: make(chan struct{}), release: make(chan struct{})}
+	reloaded := make(chan os.Signal, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app := New(nil, nil, WithSignalHandler(syscall.SIGHUP, func(context.Context) error {
+		reloaded <- syscall.SIGHUP
+		return nil
+	}))
+	app.AddRunnable("worker", r)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- app.Run(ctx) }()
+
+	<-r.started
+	is := assert.New(t)
+	is.NoError(syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+	select {
+	case sig := <-reloaded:
+		is.Equal(syscall.SIGHUP, sig)
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGHUP handler not called")
+	}
+
+	cancel()
+	close(r.release)
+	is.NoError(<-errCh)
+}
+
+func TestForceShutdownOnTimeout(t *testing.T) {
+	r := &blockingRunnable{started: make(chan struct{}), release: make(chan struct{})}
+	code := make(chan int, 1)
+
+	exit = func(c int) {
+		code <- c
+		close(r.release)
+	}
+	t.Cleanup(func() { exit = os.Exit })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app := New(nil, nil, WithShutdownTimeout(50*time.Millisecond))
+	app.AddRunnable("worker", r)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- app.Run(ctx) }()
+
+	<-r.started
+	cancel()
+
+	is := assert.New(t)
+	is.Equal(1, <-code)
+	<-errCh
+}
+
+func TestWithoutSignals(t *testing.T) {
+	r := &blockingRunnable{started: make(chan struct{}), release: make(chan struct{})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app := New(nil, nil, WithSignals())
+	app.AddRunnable("worker", r)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- app.Run(ctx) }()
+
+	<-r.started
+	is := assert.New(t)
+	is.NoError(syscall.Kill(os.Getpid(), syscall.SIGWINCH))
+
+	select {
+	case err := <-errCh:
+		t.Fatalf("stopped on SIGWINCH: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	close(r.release)
+	is.NoError(<-errCh)
+}
+
+func TestSignalConflict(t *testing.T) {
+	app := New(nil, nil, WithSignalHandler(syscall.SIGTERM, func(context.Context) error { return nil }))
+	app.AddRunnable("worker", &blockingRunnable{started: make(chan struct{}), release: make(chan struct{})})
+
+	err := app.Run(context.Background())
+	is := assert.New(t)
+	is.ErrorIs(err, ErrSignalConflict)
+	is.Equal(ExitCodeStartup, ExitCode(err))
+}
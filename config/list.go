@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// SubList returns a viper for each entry of the list at key, such as
+// http.listeners, so the entries are read with the same dotted keys as the
+// rest of the configuration. It returns nil when key is not set.
+func SubList(v *viper.Viper, key string) ([]*viper.Viper, error) {
+	raw := v.Get(key)
+	if raw == nil {
+		return nil, nil
+	}
+
+	items, err := cast.ToSliceE(raw)
+	if err != nil {
+		return nil, fmt.Errorf("core/config: %s: %w", key, err)
+	}
+
+	subs := make([]*viper.Viper, 0, len(items))
+	for i, item := range items {
+		m, err := cast.ToStringMapE(item)
+		if err != nil {
+			return nil, fmt.Errorf("core/config: %s[%d]: %w", key, i, err)
+		}
+
+		sub := viper.New()
+		if err := sub.MergeConfigMap(m); err != nil {
+			return nil, fmt.Errorf("core/config: %s[%d]: %w", key, i, err)
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubList(t *testing.T) {
+	v := viper.New()
+	v.Set("http.listeners", []map[string]any{
+		{"address": ":8081", "write": map[string]any{"timeout": "5s"}},
+		{"address": ":8082", "read.header.timeout": "1s"},
+	})
+
+	is := assert.New(t)
+	subs, err := SubList(v, "http.listeners")
+	if is.NoError(err) && is.Len(subs, 2) {
+		is.Equal(":8081", subs[0].GetString("address"))
+		is.Equal(5*time.Second, subs[0].GetDuration("write.timeout"))
+		is.Equal(time.Second, subs[1].GetDuration("read.header.timeout"))
+	}
+
+	subs, err = SubList(v, "http.groups")
+	is.NoError(err)
+	is.Nil(subs)
+
+	v.Set("http.listeners", "not a list")
+	_, err = SubList(v, "http.listeners")
+	is.Error(err)
+}
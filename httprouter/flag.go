@@ -11,23 +11,74 @@ import (
 func SetupFlags(f *pflag.FlagSet, v *viper.Viper) {
 	prefix := "http"
 
+	dashNetwork, dotNetwork := buildKey(prefix, "network")
 	dashHost, dotHost := buildKey(prefix, "host")
 	dashPort, dotPort := buildKey(prefix, "port")
 	dashIdleTimeout, dotIdleTimeout := buildKey(prefix, "server.idle.timeout")
 	dashWriteTimeout, dotWriteTimeout := buildKey(prefix, "server.write.timeout")
 	dashReadTimeout, dotReadTimeout := buildKey(prefix, "server.read.timeout")
+	dashReadHeaderTimeout, dotReadHeaderTimeout := buildKey(prefix, "server.read.header.timeout")
+	dashMaxHeaderBytes, dotMaxHeaderBytes := buildKey(prefix, "server.max.header.bytes")
+	dashDisableKeepAlive, dotDisableKeepAlive := buildKey(prefix, "server.disable.keepalive")
+	dashMaxConnections, dotMaxConnections := buildKey(prefix, "server.max.connections")
+	dashH2C, dotH2C := buildKey(prefix, "h2c")
+	dashHTTP3, dotHTTP3 := buildKey(prefix, "http3")
+	dashTLSCert, dotTLSCert := buildKey(prefix, "tls.cert")
+	dashTLSKey, dotTLSKey := buildKey(prefix, "tls.key")
+	dashTLSClientCA, dotTLSClientCA := buildKey(prefix, "tls.client.ca")
+	dashTLSClientAuth, dotTLSClientAuth := buildKey(prefix, "tls.client.auth")
+	dashCORSAllowedOrigins, dotCORSAllowedOrigins := buildKey(prefix, "cors.allowed.origins")
+	dashCORSAllowedMethods, dotCORSAllowedMethods := buildKey(prefix, "cors.allowed.methods")
+	dashCORSAllowedHeaders, dotCORSAllowedHeaders := buildKey(prefix, "cors.allowed.headers")
+	dashCORSExposedHeaders, dotCORSExposedHeaders := buildKey(prefix, "cors.exposed.headers")
+	dashCORSAllowCredentials, dotCORSAllowCredentials := buildKey(prefix, "cors.allow.credentials")
+	dashCORSMaxAge, dotCORSMaxAge := buildKey(prefix, "cors.max.age")
 
-	f.String(dashHost, "", "HTTP Server listen host (e.g. 127.0.0.1)")
+	f.String(dashNetwork, "tcp", "HTTP Server listen network (tcp, tcp4, tcp6, unix, systemd or fd)")
+	f.String(dashHost, "", "HTTP Server listen host (e.g. 127.0.0.1), socket path for unix or file descriptor for fd")
 	f.Uint(dashPort, 0, "HTTP Server listen port (e.g. 8080)")
 	f.Duration(dashIdleTimeout, 0, "HTTP Server Idle Timeout")
 	f.Duration(dashWriteTimeout, 0, "HTTP Server Write Timeout")
 	f.Duration(dashReadTimeout, 0, "HTTP Server Read Timeout")
+	f.Duration(dashReadHeaderTimeout, 0, "HTTP Server Read Header Timeout")
+	f.Int(dashMaxHeaderBytes, 0, "HTTP Server maximum size of request headers in bytes")
+	f.Bool(dashDisableKeepAlive, false, "HTTP Server closes connections after each request")
+	f.Int(dashMaxConnections, 0, "HTTP Server maximum concurrent connections per listener, 0 means no limit")
+	f.Bool(dashH2C, false, "HTTP Server accepts HTTP/2 without TLS (h2c)")
+	f.Bool(dashHTTP3, false, "HTTP Server also serves HTTP/3 over QUIC on the same port, requires TLS")
+	f.String(dashTLSCert, "", "HTTP Server TLS certificate file, reloaded when it changes")
+	f.String(dashTLSKey, "", "HTTP Server TLS private key file, reloaded when it changes")
+	f.String(dashTLSClientCA, "", "HTTP Server CA file verifying client certificates (mTLS)")
+	f.String(dashTLSClientAuth, "", "HTTP Server client certificate mode (none, request, require, verify-if-given or require-and-verify)")
+	f.StringSlice(dashCORSAllowedOrigins, nil, "CORS allowed origins (e.g. https://*.example.com)")
+	f.StringSlice(dashCORSAllowedMethods, nil, "CORS allowed methods, GET, HEAD, POST, PUT, PATCH and DELETE by default")
+	f.StringSlice(dashCORSAllowedHeaders, nil, "CORS allowed request headers, * for any")
+	f.StringSlice(dashCORSExposedHeaders, nil, "CORS response headers exposed to the browser")
+	f.Bool(dashCORSAllowCredentials, false, "CORS allows credentials")
+	f.Duration(dashCORSMaxAge, 0, "CORS preflight cache duration")
 
+	_ = v.BindPFlag(dotNetwork, f.Lookup(dashNetwork))
 	_ = v.BindPFlag(dotHost, f.Lookup(dashHost))
 	_ = v.BindPFlag(dotPort, f.Lookup(dashPort))
 	_ = v.BindPFlag(dotIdleTimeout, f.Lookup(dashIdleTimeout))
 	_ = v.BindPFlag(dotWriteTimeout, f.Lookup(dashWriteTimeout))
 	_ = v.BindPFlag(dotReadTimeout, f.Lookup(dashReadTimeout))
+	_ = v.BindPFlag(dotReadHeaderTimeout, f.Lookup(dashReadHeaderTimeout))
+	_ = v.BindPFlag(dotMaxHeaderBytes, f.Lookup(dashMaxHeaderBytes))
+	_ = v.BindPFlag(dotDisableKeepAlive, f.Lookup(dashDisableKeepAlive))
+	_ = v.BindPFlag(dotMaxConnections, f.Lookup(dashMaxConnections))
+	_ = v.BindPFlag(dotH2C, f.Lookup(dashH2C))
+	_ = v.BindPFlag(dotHTTP3, f.Lookup(dashHTTP3))
+	_ = v.BindPFlag(dotTLSCert, f.Lookup(dashTLSCert))
+	_ = v.BindPFlag(dotTLSKey, f.Lookup(dashTLSKey))
+	_ = v.BindPFlag(dotTLSClientCA, f.Lookup(dashTLSClientCA))
+	_ = v.BindPFlag(dotTLSClientAuth, f.Lookup(dashTLSClientAuth))
+	_ = v.BindPFlag(dotCORSAllowedOrigins, f.Lookup(dashCORSAllowedOrigins))
+	_ = v.BindPFlag(dotCORSAllowedMethods, f.Lookup(dashCORSAllowedMethods))
+	_ = v.BindPFlag(dotCORSAllowedHeaders, f.Lookup(dashCORSAllowedHeaders))
+	_ = v.BindPFlag(dotCORSExposedHeaders, f.Lookup(dashCORSExposedHeaders))
+	_ = v.BindPFlag(dotCORSAllowCredentials, f.Lookup(dashCORSAllowCredentials))
+	_ = v.BindPFlag(dotCORSMaxAge, f.Lookup(dashCORSMaxAge))
 }
 
 func ternary[T any](condition bool, ifOutput T, elseOutput T) T {
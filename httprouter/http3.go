@@ -0,0 +1,42 @@
+package httprouter
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+var ErrHTTP3WithoutTLS = errors.New("core/httprouter: HTTP/3 requires TLS")
+
+// listenHTTP3 returns the HTTP/3 server enabled by the http.http3 key,
+// listening on the UDP port matching the TCP address addr, or nil when
+// disabled.
+func (e *server) listenHTTP3(addr net.Addr, tlsConfig *tls.Config, h http.Handler) (*http3.Server, net.PacketConn, error) {
+	if !e.config.GetBool("http.http3") {
+		return nil, nil, nil
+	}
+
+	tcp, ok := addr.(*net.TCPAddr)
+	if tlsConfig == nil || !ok {
+		return nil, nil, ErrHTTP3WithoutTLS
+	}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: tcp.IP, Port: tcp.Port, Zone: tcp.Zone})
+	if err != nil {
+		return nil, nil, fmt.Errorf("core/httprouter: http3: %w", err)
+	}
+
+	return &http3.Server{Handler: h, TLSConfig: tlsConfig}, conn, nil
+}
+
+// altSvc advertises the HTTP/3 server on the responses of h.
+func altSvc(h3 *http3.Server, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = h3.SetQUICHeaders(w.Header())
+		h.ServeHTTP(w, r)
+	})
+}
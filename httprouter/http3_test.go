@@ -0,0 +1,60 @@
+package httprouter
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTP3(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	cert := newTestCert(t, 1, nil)
+	cert.write(t, certFile, keyFile)
+
+	v := viper.New()
+	v.Set("http.host", "127.0.0.1")
+	v.Set("http.tls.cert", certFile)
+	v.Set("http.tls.key", keyFile)
+	v.Set("http.http3", true)
+
+	s := New(v)
+	s.GET("/proto", func(c *gin.Context) { c.String(http.StatusOK, c.Request.Proto) })
+	serve(t, s)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert.cert)
+	url := "https://" + s.Addr().String() + "/proto"
+
+	is := assert.New(t)
+
+	res, err := (&http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}).Get(url)
+	if is.NoError(err) {
+		_ = res.Body.Close()
+		is.Contains(res.Header.Get("Alt-Svc"), `h3=":`)
+	}
+
+	h3 := &http3.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}
+	defer h3.Close()
+
+	body, err := get(&http.Client{Transport: h3}, url)
+	is.NoError(err)
+	is.Equal("HTTP/3.0", body)
+}
+
+func TestHTTP3WithoutTLS(t *testing.T) {
+	v := viper.New()
+	v.Set("http.http3", true)
+
+	err := New(v).Run("127.0.0.1:0")
+
+	is := assert.New(t)
+	is.ErrorIs(err, ErrHTTP3WithoutTLS)
+}
@@ -0,0 +1,177 @@
+package httprouter
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cyg-pd/go-core/config"
+	"github.com/spf13/viper"
+)
+
+// Networks accepted by the http.network key, besides the ones of net.Listen
+// such as tcp, tcp4, tcp6 and unix, whose socket path is read from http.host.
+const (
+	// NetworkSystemd serves the first socket passed by systemd socket
+	// activation, see SystemdListeners.
+	NetworkSystemd = "systemd"
+	// NetworkFD serves an inherited file descriptor, whose number is read
+	// from http.host, for instance on zero-downtime restarts.
+	NetworkFD = "fd"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd.
+const listenFDsStart = 3
+
+var ErrNoSystemdListener = errors.New("core/httprouter: no socket passed by systemd")
+
+// Listener is an address served by the engine alongside the configured
+// one, such as a localhost-only admin port or an IPv6 address, see
+// WithListeners and the http.listeners key. Zero timeouts and limits keep
+// the ones of the server.
+type Listener struct {
+	Network           string
+	Address           string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxConnections    int
+}
+
+// listenerFromConfig reads a listener from an entry of http.listeners, with
+// the keys of the server settings it overrides, such as write.timeout for
+// http.server.write.timeout.
+func listenerFromConfig(v *viper.Viper) Listener {
+	return Listener{
+		Network:           v.GetString("network"),
+		Address:           v.GetString("address"),
+		ReadTimeout:       v.GetDuration("read.timeout"),
+		ReadHeaderTimeout: v.GetDuration("read.header.timeout"),
+		WriteTimeout:      v.GetDuration("write.timeout"),
+		IdleTimeout:       v.GetDuration("idle.timeout"),
+		MaxConnections:    v.GetInt("max.connections"),
+	}
+}
+
+// listeners returns the addresses to serve: the hosts passed to Run or the
+// configured address first, then the additional listeners.
+func (e *server) listeners(host ...string) ([]Listener, error) {
+	network := e.config.GetString("http.network")
+	if network == "" {
+		network = "tcp"
+	}
+
+	var ls []Listener
+	for _, h := range host {
+		ls = append(ls, Listener{Network: network, Address: h})
+	}
+
+	if len(ls) == 0 {
+		address := e.config.GetString("http.host")
+		if strings.HasPrefix(network, "tcp") {
+			address += ":" + strconv.Itoa(e.config.GetInt("http.port"))
+		}
+		ls = append(ls, Listener{Network: network, Address: address})
+	}
+
+	configured, err := config.SubList(e.config, "http.listeners")
+	if err != nil {
+		return nil, fmt.Errorf("core/httprouter: %w", err)
+	}
+
+	ls = append(ls, e.extraListeners...)
+	for _, c := range configured {
+		ls = append(ls, listenerFromConfig(c))
+	}
+	return ls, nil
+}
+
+func listen(l Listener) (net.Listener, error) {
+	network, address := l.Network, l.Address
+	if network == "" {
+		network = "tcp"
+	}
+
+	switch network {
+	case NetworkSystemd:
+		lns, err := SystemdListeners()
+		if err != nil {
+			return nil, err
+		}
+		if len(lns) == 0 {
+			return nil, ErrNoSystemdListener
+		}
+		for _, ln := range lns[1:] {
+			_ = ln.Close()
+		}
+		return lns[0], nil
+	case NetworkFD:
+		fd, err := strconv.ParseUint(address, 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("core/httprouter: invalid file descriptor %q: %w", address, err)
+		}
+		return FileListener(uintptr(fd))
+	default:
+		ln, err := net.Listen(network, address)
+		if err != nil {
+			return nil, fmt.Errorf("core/httprouter: %w", err)
+		}
+		return ln, nil
+	}
+}
+
+// FileListener returns a listener for the socket open on the inherited file
+// descriptor fd, which it takes over: fd is closed once duplicated.
+func FileListener(fd uintptr) (net.Listener, error) {
+	f := os.NewFile(fd, "fd"+strconv.FormatUint(uint64(fd), 10))
+	if f == nil {
+		return nil, fmt.Errorf("core/httprouter: invalid file descriptor %d", fd)
+	}
+	defer f.Close()
+
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("core/httprouter: file descriptor %d: %w", fd, err)
+	}
+	return ln, nil
+}
+
+// SystemdListeners returns the sockets passed by systemd socket activation,
+// following the LISTEN_PID and LISTEN_FDS environment variables, in order.
+// The variables are unset, so the sockets are only returned once and not
+// passed on to child processes.
+func SystemdListeners() ([]net.Listener, error) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+
+	lns := make([]net.Listener, 0, n)
+	for fd := listenFDsStart; fd < listenFDsStart+n; fd++ {
+		ln, err := FileListener(uintptr(fd))
+		if err != nil {
+			for _, ln := range lns {
+				_ = ln.Close()
+			}
+			return nil, err
+		}
+		lns = append(lns, ln)
+	}
+
+	return lns, nil
+}
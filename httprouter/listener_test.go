@@ -0,0 +1,130 @@
+package httprouter
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(t *testing.T, s *Server) {
+	t.Helper()
+
+	s.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+	go func() { _ = s.Run() }()
+	<-s.Listening()
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+}
+
+func get(client *http.Client, url string) (string, error) {
+	res, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	return string(body), err
+}
+
+func TestWithListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(viper.New(), WithListener(ln))
+	serve(t, s)
+
+	is := assert.New(t)
+	is.Equal(ln.Addr().String(), s.Addr().String())
+
+	body, err := get(http.DefaultClient, "http://"+s.Addr().String()+"/ping")
+	is.NoError(err)
+	is.Equal("pong", body)
+}
+
+func TestUnixNetwork(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "http.sock")
+
+	v := viper.New()
+	v.Set("http.network", "unix")
+	v.Set("http.host", path)
+
+	s := New(v)
+	serve(t, s)
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+
+	is := assert.New(t)
+	is.Equal(path, s.Addr().String())
+
+	body, err := get(client, "http://unix/ping")
+	is.NoError(err)
+	is.Equal("pong", body)
+}
+
+func TestFileListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// FileListener takes over the descriptor of f.
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inherited, err := FileListener(f.Fd())
+
+	is := assert.New(t)
+	if is.NoError(err) {
+		is.Equal(ln.Addr().String(), inherited.Addr().String())
+		_ = inherited.Close()
+	}
+}
+
+func TestMultipleListeners(t *testing.T) {
+	v := viper.New()
+	v.Set("http.host", "127.0.0.1")
+	v.Set("http.listeners", []map[string]any{
+		{"address": "127.0.0.1:0", "write.timeout": "5s"},
+	})
+
+	s := New(v, WithListeners(Listener{Address: "127.0.0.1:0", IdleTimeout: time.Second}))
+	serve(t, s)
+
+	is := assert.New(t)
+	addrs := s.Addrs()
+	is.Len(addrs, 3)
+
+	for _, addr := range addrs {
+		body, err := get(http.DefaultClient, "http://"+addr.String()+"/ping")
+		is.NoError(err)
+		is.Equal("pong", body)
+	}
+
+	e := s.Engine.(*server)
+	is.Equal(time.Second, e.servers[1].IdleTimeout)
+	is.Equal(5*time.Second, e.servers[2].WriteTimeout)
+	is.Equal(60*time.Second, e.servers[0].WriteTimeout)
+
+	is.NoError(s.Shutdown(context.Background()))
+	for _, addr := range addrs {
+		_, err := get(http.DefaultClient, "http://"+addr.String()+"/ping")
+		is.Error(err)
+	}
+}
@@ -11,6 +11,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// accessLogMaxBodySize caps the request bodies logged when
+// WithAccessLogMaxBodySize is 0.
+const accessLogMaxBodySize = 1 << 20
+
 // accessLogOption applies a configuration accessLogOption value to a http.Client.
 type accessLogOption interface{ apply(*AccessLog) }
 
@@ -57,17 +61,29 @@ func (a AccessLog) reqBody(req *http.Request) slog.Attr {
 		return slog.Attr{}
 	}
 
-	// content length bigger then 100kb ignore read body
-	if a.maxBodySize > 0 && req.ContentLength > a.maxBodySize {
+	// Without a limit, bodies are still logged up to accessLogMaxBodySize, so
+	// a chunked body of unknown length is never read whole into memory.
+	limit := a.maxBodySize
+	if limit == 0 {
+		limit = accessLogMaxBodySize
+	}
+
+	// content length bigger then the limit ignore read body
+	if req.ContentLength > limit {
 		return slog.Attr{}
 	}
 
-	b, err := io.ReadAll(req.Body)
-	_ = req.Body.Close()
+	body := req.Body
+	b, err := io.ReadAll(io.LimitReader(body, limit+1))
 	if err != nil {
+		_ = body.Close()
+		return slog.Attr{}
+	}
+	req.Body = readCloser{io.MultiReader(bytes.NewReader(b), body), body}
+
+	if int64(len(b)) > limit {
 		return slog.Attr{}
 	}
-	req.Body = io.NopCloser(bytes.NewReader(b))
 
 	return slog.String("body", string(b))
 }
@@ -174,3 +190,8 @@ func (w bodyLogWriter) Write(b []byte) (int, error) {
 	w.buf.Write(b)
 	return w.ResponseWriter.Write(b)
 }
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -283,3 +283,29 @@ func TestGinResError(t *testing.T) {
 	"time": "`+cast.ToString(logData["time"])+`"
 `), buf.String())
 }
+
+func TestReqBodyUnknownLength(t *testing.T) {
+	buf := &bytes.Buffer{}
+	defer mockSlog(buf)()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewAccessLog(WithAccessLogMaxBodySize(0)).Middleware())
+	router.POST("/upload", func(ctx *gin.Context) {
+		b, _ := io.ReadAll(ctx.Request.Body)
+		ctx.String(http.StatusOK, "%d", len(b))
+	})
+
+	// A reader of unknown length, as a chunked body.
+	body := io.MultiReader(strings.NewReader(strings.Repeat("a", accessLogMaxBodySize)), strings.NewReader("b"))
+	w := PerformRequest(router, http.MethodPost, "/upload", body)
+
+	is := assert.New(t)
+	is.Equal(cast.ToString(accessLogMaxBodySize+1), w.Body.String())
+
+	var logData struct {
+		Req map[string]any `json:"req"`
+	}
+	is.NoError(json.Unmarshal(buf.Bytes(), &logData))
+	is.NotContains(logData.Req, "body")
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// apiKeyOption applies a configuration apiKeyOption value to APIKeys.
+type apiKeyOption interface{ apply(*APIKeys) }
+
+// apiKeyOptionFunc applies a set of options to a config.
+type apiKeyOptionFunc func(*APIKeys)
+
+// apply returns a config with option(s) applied.
+func (o apiKeyOptionFunc) apply(conf *APIKeys) { o(conf) }
+
+// WithAPIKeyHeader sets the header carrying the key, "X-API-Key" by default.
+func WithAPIKeyHeader(name string) apiKeyOption {
+	return apiKeyOptionFunc(func(conf *APIKeys) {
+		conf.header = name
+	})
+}
+
+// WithAPIKey accepts key, authenticating its requests as p.
+func WithAPIKey(key string, p Principal) apiKeyOption {
+	return apiKeyOptionFunc(func(conf *APIKeys) {
+		conf.keys[sha256.Sum256([]byte(key))] = p
+	})
+}
+
+// WithAPIKeyHash accepts the key whose SHA-256 hash, hex encoded, is hash,
+// authenticating its requests as p. Unlike WithAPIKey, the key itself need
+// not be kept in the configuration, see HashAPIKey.
+func WithAPIKeyHash(hash string, p Principal) apiKeyOption {
+	return apiKeyOptionFunc(func(conf *APIKeys) {
+		var sum [sha256.Size]byte
+		if n, err := hex.Decode(sum[:], []byte(hash)); err != nil || n != sha256.Size {
+			conf.errs = append(conf.errs, fmt.Errorf(pkg+": invalid API key hash for %q", p.Subject))
+			return
+		}
+		conf.keys[sum] = p
+	})
+}
+
+// HashAPIKey returns the hash of key expected by WithAPIKeyHash.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeys authenticates requests by a static API key in a header. Keys are
+// matched by their SHA-256 hash, so lookups do not leak the keys through
+// timing.
+type APIKeys struct {
+	header string
+	keys   map[[sha256.Size]byte]Principal
+	errs   []error
+}
+
+// NewAPIKeys returns an Authenticator accepting the keys set by WithAPIKey
+// and WithAPIKeyHash.
+func NewAPIKeys(opts ...apiKeyOption) (*APIKeys, error) {
+	a := &APIKeys{
+		header: "X-API-Key",
+		keys:   map[[sha256.Size]byte]Principal{},
+	}
+	for _, opt := range opts {
+		opt.apply(a)
+	}
+	return a, errors.Join(a.errs...)
+}
+
+func (a *APIKeys) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(a.header)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	p, ok := a.keys[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+	}
+
+	p.Method = "api_key"
+	return &p, nil
+}
+
+var _ Authenticator = (*APIKeys)(nil)
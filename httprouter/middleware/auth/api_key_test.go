@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeys(t *testing.T) {
+	is := assert.New(t)
+
+	a, err := NewAPIKeys(
+		WithAPIKey("static-key", Principal{Subject: "ci", Roles: []string{"deployer"}}),
+		WithAPIKeyHash(HashAPIKey("hashed-key"), Principal{Subject: "batch"}),
+	)
+	is.NoError(err)
+
+	request := func(key string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if key != "" {
+			r.Header.Set("X-API-Key", key)
+		}
+		return r
+	}
+
+	p, err := a.Authenticate(request("static-key"))
+	if is.NoError(err) {
+		is.Equal("ci", p.Subject)
+		is.Equal("api_key", p.Method)
+		is.Equal([]string{"deployer"}, p.Roles)
+	}
+
+	p, err = a.Authenticate(request("hashed-key"))
+	if is.NoError(err) {
+		is.Equal("batch", p.Subject)
+	}
+
+	_, err = a.Authenticate(request("wrong-key"))
+	is.ErrorIs(err, ErrInvalidCredentials)
+
+	_, err = a.Authenticate(request(""))
+	is.ErrorIs(err, ErrNoCredentials)
+
+	_, err = NewAPIKeys(WithAPIKeyHash("not-hex", Principal{Subject: "batch"}))
+	is.Error(err)
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/cyg-pd/go-core/httprouter/render"
+	"github.com/gin-gonic/gin"
+)
+
+const pkg = "core/httprouter/middleware/auth"
+
+// PrincipalKey is the gin.Context key of the principal of the request, see
+// FromContext.
+const PrincipalKey = pkg + "/principal"
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request
+	// carries no credentials it handles.
+	ErrNoCredentials = errors.New(pkg + ": no credentials")
+	// ErrInvalidCredentials is wrapped by the errors returned by an
+	// Authenticator when the credentials are invalid, expired or unknown.
+	ErrInvalidCredentials = errors.New(pkg + ": invalid credentials")
+	// ErrUnavailable is rendered by Authenticate when credentials cannot be
+	// checked, such as when the JWKS is unreachable.
+	ErrUnavailable = errors.New(pkg + ": authentication unavailable")
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject identifies the caller, such as the sub claim of a JWT.
+	Subject string
+	// Method is the authenticator that resolved the principal: "jwt",
+	// "api_key" or "mtls".
+	Method string
+	Scopes []string
+	Roles  []string
+	// Claims holds the claims of a JWT.
+	Claims map[string]any
+	// Certificate is the client certificate of an mTLS connection.
+	Certificate *x509.Certificate
+}
+
+func (p *Principal) HasScope(scope string) bool { return slices.Contains(p.Scopes, scope) }
+func (p *Principal) HasRole(role string) bool   { return slices.Contains(p.Roles, role) }
+
+// Authenticator resolves the principal of a request. It returns
+// ErrNoCredentials when the request carries none of its credentials, and an
+// error wrapping ErrInvalidCredentials when they are rejected.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// AuthenticatorFunc adapts a function to an Authenticator.
+type AuthenticatorFunc func(r *http.Request) (*Principal, error)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*Principal, error) { return f(r) }
+
+// Any tries each authenticator in turn, until one finds credentials in the
+// request.
+func Any(authenticators ...Authenticator) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+		for _, a := range authenticators {
+			p, err := a.Authenticate(r)
+			if !errors.Is(err, ErrNoCredentials) {
+				return p, err
+			}
+		}
+		return nil, ErrNoCredentials
+	})
+}
+
+// option applies a configuration option value to Authenticate.
+type option interface{ apply(*config) }
+
+// optionFunc applies a set of options to a config.
+type optionFunc func(*config)
+
+// apply returns a config with option(s) applied.
+func (o optionFunc) apply(conf *config) { o(conf) }
+
+// WithOptional lets requests without credentials through, without a
+// principal, so routes can serve anonymous callers. Invalid credentials are
+// still rejected.
+func WithOptional() option {
+	return optionFunc(func(conf *config) {
+		conf.optional = true
+	})
+}
+
+type config struct {
+	optional bool
+}
+
+// Authenticate resolves the principal of each request with a and stores it
+// on the gin.Context and in the request context, see FromContext. Requests
+// without credentials or with invalid ones are answered with 401, other
+// failures, such as unreachable JWKS, with 503. Only the generic errors are
+// rendered, details are logged.
+func Authenticate(a Authenticator, opts ...option) gin.HandlerFunc {
+	conf := &config{}
+	for _, opt := range opts {
+		opt.apply(conf)
+	}
+
+	return func(c *gin.Context) {
+		p, err := a.Authenticate(c.Request)
+		switch {
+		case err == nil:
+			c.Set(PrincipalKey, p)
+			c.Request = c.Request.WithContext(NewContext(c.Request.Context(), p))
+		case errors.Is(err, ErrNoCredentials) && conf.optional:
+			// anonymous
+		case errors.Is(err, ErrNoCredentials):
+			render.RenderError(c, &render.StatusError{Code: http.StatusUnauthorized, Err: ErrNoCredentials})
+			c.Abort()
+		case errors.Is(err, ErrInvalidCredentials):
+			slog.DebugContext(c, pkg+": authenticate: "+err.Error())
+			render.RenderError(c, &render.StatusError{Code: http.StatusUnauthorized, Err: ErrInvalidCredentials})
+			c.Abort()
+		default:
+			slog.ErrorContext(c, pkg+": authenticate: "+err.Error())
+			render.RenderError(c, &render.StatusError{Code: http.StatusServiceUnavailable, Err: ErrUnavailable})
+			c.Abort()
+		}
+	}
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx holding p.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal stored by Authenticate, from either the
+// *gin.Context or the request context.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	if c, ok := ctx.(*gin.Context); ok {
+		if v, ok := c.Get(PrincipalKey); ok {
+			p, ok := v.(*Principal)
+			return p, ok
+		}
+		if c.Request == nil {
+			return nil, false
+		}
+		ctx = c.Request.Context()
+	}
+
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveAuth(a Authenticator, header string, opts ...option) (*httptest.ResponseRecorder, *Principal) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Authenticate(a, opts...))
+
+	var principal *Principal
+	router.GET("/", func(c *gin.Context) {
+		p, _ := FromContext(c)
+		fromReq, _ := FromContext(c.Request.Context())
+		if p == fromReq {
+			principal = p
+		}
+		c.Status(http.StatusOK)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if header != "" {
+		r.Header.Set("X-API-Key", header)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w, principal
+}
+
+func TestAuthenticate(t *testing.T) {
+	keys, _ := NewAPIKeys(WithAPIKey("key", Principal{Subject: "ci"}))
+	is := assert.New(t)
+
+	w, p := serveAuth(keys, "key")
+	is.Equal(http.StatusOK, w.Code)
+	if is.NotNil(p) {
+		is.Equal("ci", p.Subject)
+	}
+
+	w, _ = serveAuth(keys, "wrong")
+	is.Equal(http.StatusUnauthorized, w.Code)
+
+	w, _ = serveAuth(keys, "")
+	is.Equal(http.StatusUnauthorized, w.Code)
+
+	w, p = serveAuth(keys, "", WithOptional())
+	is.Equal(http.StatusOK, w.Code)
+	is.Nil(p)
+
+	w, _ = serveAuth(keys, "wrong", WithOptional())
+	is.Equal(http.StatusUnauthorized, w.Code)
+
+	failing := AuthenticatorFunc(func(*http.Request) (*Principal, error) {
+		return nil, errors.New("fetch https://idp.internal/jwks: refused")
+	})
+	w, _ = serveAuth(failing, "key")
+	is.Equal(http.StatusServiceUnavailable, w.Code)
+	is.NotContains(w.Body.String(), "idp.internal")
+}
+
+func TestAny(t *testing.T) {
+	keys, _ := NewAPIKeys(WithAPIKey("key", Principal{Subject: "ci"}))
+	a := Any(NewMTLS(), keys)
+	is := assert.New(t)
+
+	w, p := serveAuth(a, "key")
+	is.Equal(http.StatusOK, w.Code)
+	if is.NotNil(p) {
+		is.Equal("api_key", p.Method)
+	}
+
+	w, _ = serveAuth(a, "")
+	is.Equal(http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,4 @@
+// Package auth provides middleware resolving the principal of requests from
+// JWTs, API keys or mTLS client certificates, and authorizing them with
+// policies attached to routes.
+package auth
@@ -0,0 +1,300 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cyg-pd/go-core/httpclient"
+)
+
+// KeySet resolves the public key a JWT is signed with from its kid header.
+type KeySet interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// jwksOption applies a configuration jwksOption value to a JWKS.
+type jwksOption interface{ apply(*JWKS) }
+
+// jwksOptionFunc applies a set of options to a config.
+type jwksOptionFunc func(*JWKS)
+
+// apply returns a config with option(s) applied.
+func (o jwksOptionFunc) apply(conf *JWKS) { o(conf) }
+
+// WithJWKSClient sets the client fetching the keys, built by httpclient.New
+// by default.
+func WithJWKSClient(client *http.Client) jwksOption {
+	return jwksOptionFunc(func(conf *JWKS) {
+		conf.client = client
+	})
+}
+
+// WithJWKSRefresh sets how long the keys are cached, one hour by default.
+func WithJWKSRefresh(d time.Duration) jwksOption {
+	return jwksOptionFunc(func(conf *JWKS) {
+		conf.refresh = d
+	})
+}
+
+// WithJWKSMinRefresh sets the minimum delay between two fetches, one minute
+// by default, bounding the fetches caused by tokens with unknown kids.
+func WithJWKSMinRefresh(d time.Duration) jwksOption {
+	return jwksOptionFunc(func(conf *JWKS) {
+		conf.minRefresh = d
+	})
+}
+
+// JWKS is a KeySet fetching a JSON Web Key Set from a URL, such as the
+// jwks_uri of an OpenID provider. The keys are cached and fetched again once
+// expired, or earlier when a token is signed with an unknown kid, so rotated
+// keys are picked up. Expired keys are served while they are refreshed in the
+// background, and kept while the URL is unreachable.
+type JWKS struct {
+	url        string
+	client     *http.Client
+	refresh    time.Duration
+	minRefresh time.Duration
+
+	mu      sync.RWMutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+
+	// fetchMu guards the fetch in flight, attempted and err track the last
+	// one.
+	fetchMu   sync.Mutex
+	inflight  chan struct{}
+	attempted time.Time
+	err       error
+}
+
+// NewJWKS returns a KeySet fetching its keys from url.
+func NewJWKS(url string, opts ...jwksOption) *JWKS {
+	s := &JWKS{
+		url:        url,
+		refresh:    time.Hour,
+		minRefresh: time.Minute,
+	}
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+	if s.client == nil {
+		s.client = httpclient.New(httpclient.WithTimeout(10 * time.Second))
+	}
+	return s
+}
+
+// Key returns the key identified by kid, or the only key of the set when kid
+// is empty.
+func (s *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.RLock()
+	key, ok := lookupKey(s.keys, kid)
+	expired := time.Since(s.fetched) > s.refresh
+	s.mu.RUnlock()
+
+	if ok {
+		if expired {
+			s.fetch(ctx)
+		}
+		return key, nil
+	}
+
+	select {
+	case <-s.fetch(ctx):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.RLock()
+	key, ok = lookupKey(s.keys, kid)
+	s.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	s.fetchMu.Lock()
+	err := s.err
+	s.fetchMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidCredentials, kid)
+}
+
+// closed is returned by fetch when no fetch is started.
+var closed = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// fetch replaces the keys in the background and returns a channel closed once
+// done. Callers share the fetch in flight, and none is started less than
+// minRefresh after the last attempt, failed or not. The fetch is not cancelled
+// with ctx, only bounded by the client timeout.
+func (s *JWKS) fetch(ctx context.Context) <-chan struct{} {
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+
+	if s.inflight != nil {
+		return s.inflight
+	}
+	if time.Since(s.attempted) < s.minRefresh {
+		return closed
+	}
+
+	done := make(chan struct{})
+	s.inflight = done
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer close(done)
+
+		keys, err := s.get(ctx)
+		if err != nil {
+			err = fmt.Errorf(pkg+": fetch JWKS: %w", err)
+			slog.WarnContext(ctx, err.Error())
+		} else {
+			s.mu.Lock()
+			s.keys, s.fetched = keys, time.Now()
+			s.mu.Unlock()
+		}
+
+		s.fetchMu.Lock()
+		s.inflight, s.attempted, s.err = nil, time.Now(), err
+		s.fetchMu.Unlock()
+	}()
+	return done
+}
+
+func (s *JWKS) get(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New("unexpected status " + res.Status)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			slog.WarnContext(ctx, pkg+": skip JWK "+k.Kid+": "+err.Error())
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func lookupKey(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	key, ok := keys[kid]
+	return key, ok
+}
+
+// jwk is a JSON Web Key, see RFC 7517.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, errors.New("unsupported curve " + k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC point")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errors.New("unsupported curve " + k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, errors.New("unsupported key type " + k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// jwtAlgorithms lists the supported JWS algorithms with their hash. HMAC and
+// "none" are not supported, tokens must be signed with a published key.
+var jwtAlgorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+	"EdDSA": 0,
+}
+
+// jwtOption applies a configuration jwtOption value to a JWT.
+type jwtOption interface{ apply(*JWT) }
+
+// jwtOptionFunc applies a set of options to a config.
+type jwtOptionFunc func(*JWT)
+
+// apply returns a config with option(s) applied.
+func (o jwtOptionFunc) apply(conf *JWT) { o(conf) }
+
+// WithJWTIssuer requires the iss claim to be issuer.
+func WithJWTIssuer(issuer string) jwtOption {
+	return jwtOptionFunc(func(conf *JWT) {
+		conf.issuer = issuer
+	})
+}
+
+// WithJWTAudience requires the aud claim to hold one of audience.
+func WithJWTAudience(audience ...string) jwtOption {
+	return jwtOptionFunc(func(conf *JWT) {
+		conf.audience = audience
+	})
+}
+
+// WithJWTAlgorithms restricts the accepted algorithms, such as "RS256". All
+// the supported asymmetric algorithms are accepted by default.
+func WithJWTAlgorithms(algorithms ...string) jwtOption {
+	return jwtOptionFunc(func(conf *JWT) {
+		conf.algorithms = algorithms
+	})
+}
+
+// WithJWTLeeway sets the clock skew tolerated on the exp and nbf claims, 30
+// seconds by default.
+func WithJWTLeeway(d time.Duration) jwtOption {
+	return jwtOptionFunc(func(conf *JWT) {
+		conf.leeway = d
+	})
+}
+
+// WithJWTRolesClaim sets the claim holding the roles, "roles" by default.
+func WithJWTRolesClaim(name string) jwtOption {
+	return jwtOptionFunc(func(conf *JWT) {
+		conf.rolesClaim = name
+	})
+}
+
+// JWT authenticates requests by a bearer token in the Authorization header,
+// a JWT signed with a key of a KeySet, such as JWKS. The token must carry an
+// exp claim. The scopes are read from the scope or scp claim.
+type JWT struct {
+	keys       KeySet
+	issuer     string
+	audience   []string
+	algorithms []string
+	leeway     time.Duration
+	rolesClaim string
+}
+
+// NewJWT returns an Authenticator verifying tokens with keys.
+func NewJWT(keys KeySet, opts ...jwtOption) *JWT {
+	j := &JWT{
+		keys:       keys,
+		leeway:     30 * time.Second,
+		rolesClaim: "roles",
+	}
+	for _, opt := range opts {
+		opt.apply(j)
+	}
+	return j
+}
+
+func (j *JWT) Authenticate(r *http.Request) (*Principal, error) {
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := j.verify(r.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, _ := claims["sub"].(string)
+	scopes := claimStrings(claims["scope"])
+	if len(scopes) == 0 {
+		scopes = claimStrings(claims["scp"])
+	}
+
+	return &Principal{
+		Subject: sub,
+		Method:  "jwt",
+		Scopes:  scopes,
+		Roles:   claimStrings(claims[j.rolesClaim]),
+		Claims:  claims,
+	}, nil
+}
+
+// verify checks the signature and the registered claims of token and
+// returns its claims.
+func (j *JWT) verify(ctx context.Context, token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	hash, ok := jwtAlgorithms[header.Alg]
+	if !ok || (len(j.algorithms) > 0 && !slices.Contains(j.algorithms, header.Alg)) {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidCredentials, header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidCredentials)
+	}
+
+	key, err := j.keys.Key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifySignature(header.Alg, hash, key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	return claims, j.validate(claims)
+}
+
+func (j *JWT) validate(claims map[string]any) error {
+	now := time.Now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("%w: missing exp claim", ErrInvalidCredentials)
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(j.leeway)) {
+		return fmt.Errorf("%w: token expired", ErrInvalidCredentials)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(j.leeway).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("%w: token not yet valid", ErrInvalidCredentials)
+	}
+
+	if iss, _ := claims["iss"].(string); j.issuer != "" && iss != j.issuer {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidCredentials, iss)
+	}
+
+	if len(j.audience) > 0 && !slices.ContainsFunc(claimStrings(claims["aud"]), func(aud string) bool {
+		return slices.Contains(j.audience, aud)
+	}) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidCredentials)
+	}
+
+	return nil
+}
+
+func verifySignature(alg string, hash crypto.Hash, key crypto.PublicKey, input string, sig []byte) error {
+	var digest []byte
+	if hash != 0 {
+		h := hash.New()
+		h.Write([]byte(input))
+		digest = h.Sum(nil)
+	}
+
+	valid := false
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			valid = rsa.VerifyPKCS1v15(key, hash, digest, sig) == nil
+		case "PS":
+			valid = rsa.VerifyPSS(key, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if alg[:2] == "ES" && len(sig) == 2*size {
+			r := new(big.Int).SetBytes(sig[:size])
+			s := new(big.Int).SetBytes(sig[size:])
+			valid = ecdsa.Verify(key, digest, r, s)
+		}
+	case ed25519.PublicKey:
+		valid = alg == "EdDSA" && ed25519.Verify(key, []byte(input), sig)
+	}
+
+	if !valid {
+		return fmt.Errorf("%w: invalid signature", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+func decodeSegment(s string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(b, v)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+// claimStrings reads a claim holding either a space separated string or an
+// array of strings.
+func claimStrings(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		s := make([]string, 0, len(v))
+		for _, e := range v {
+			if e, ok := e.(string); ok {
+				s = append(s, e)
+			}
+		}
+		return s
+	}
+	return nil
+}
+
+var _ Authenticator = (*JWT)(nil)
@@ -0,0 +1,339 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testKey is a signing key published by testJWKS.
+type testKey struct {
+	kid string
+	alg string
+	key crypto.Signer
+}
+
+func newTestKey(t *testing.T, kid, alg string) testKey {
+	var (
+		key crypto.Signer
+		err error
+	)
+	switch alg {
+	case "RS256", "PS256":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "EdDSA":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{kid: kid, alg: alg, key: key}
+}
+
+func (k testKey) jwk() map[string]string {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch pub := k.key.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": k.kid, "n": b64(pub.N.Bytes()), "e": b64(big.NewInt(int64(pub.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kty": "EC", "kid": k.kid, "crv": "P-256", "x": b64(pub.X.FillBytes(make([]byte, 32))), "y": b64(pub.Y.FillBytes(make([]byte, 32)))}
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "kid": k.kid, "crv": "Ed25519", "x": b64(pub)}
+	}
+	return nil
+}
+
+func (k testKey) sign(t *testing.T, claims map[string]any) string {
+	enc := func(v any) string {
+		b, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	input := enc(map[string]string{"alg": k.alg, "kid": k.kid, "typ": "JWT"}) + "." + enc(claims)
+	digest := sha256.Sum256([]byte(input))
+
+	var (
+		sig []byte
+		err error
+	)
+	switch key := k.key.(type) {
+	case *rsa.PrivateKey:
+		if k.alg == "PS256" {
+			sig, err = rsa.SignPSS(rand.Reader, key, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			sig, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		}
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key, digest[:])
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(key, []byte(input))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// testJWKS serves the keys it holds and counts the fetches.
+type testJWKS struct {
+	mu      sync.Mutex
+	keys    []testKey
+	fetches atomic.Int32
+}
+
+func (s *testJWKS) set(keys ...testKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *testJWKS) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.fetches.Add(1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set := map[string][]map[string]string{"keys": {}}
+	for _, k := range s.keys {
+		set["keys"] = append(set["keys"], k.jwk())
+	}
+	_ = json.NewEncoder(w).Encode(set)
+}
+
+func bearer(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":   "alice",
+		"iss":   "https://issuer.test",
+		"aud":   []string{"api"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "read write",
+		"roles": []string{"admin"},
+	}
+}
+
+func TestJWT(t *testing.T) {
+	keys := []testKey{
+		newTestKey(t, "rs", "RS256"),
+		newTestKey(t, "ps", "PS256"),
+		newTestKey(t, "es", "ES256"),
+		newTestKey(t, "ed", "EdDSA"),
+	}
+	jwks := &testJWKS{}
+	jwks.set(keys...)
+	srv := httptest.NewServer(jwks)
+	defer srv.Close()
+
+	a := NewJWT(NewJWKS(srv.URL), WithJWTIssuer("https://issuer.test"), WithJWTAudience("api"))
+
+	is := assert.New(t)
+	for _, k := range keys {
+		p, err := a.Authenticate(bearer(k.sign(t, validClaims())))
+		if is.NoError(err, k.alg) {
+			is.Equal("alice", p.Subject)
+			is.Equal("jwt", p.Method)
+			is.Equal([]string{"read", "write"}, p.Scopes)
+			is.Equal([]string{"admin"}, p.Roles)
+		}
+	}
+	is.Equal(int32(1), jwks.fetches.Load())
+
+	_, err := a.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	is.ErrorIs(err, ErrNoCredentials)
+}
+
+func TestJWTInvalid(t *testing.T) {
+	key := newTestKey(t, "rs", "RS256")
+	other := newTestKey(t, "rs", "RS256")
+	jwks := &testJWKS{}
+	jwks.set(key)
+	srv := httptest.NewServer(jwks)
+	defer srv.Close()
+
+	a := NewJWT(NewJWKS(srv.URL), WithJWTIssuer("https://issuer.test"), WithJWTAudience("api"))
+	claims := func(k string, v any) map[string]any {
+		c := validClaims()
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+		return c
+	}
+
+	tokens := map[string]string{
+		"expired":   key.sign(t, claims("exp", time.Now().Add(-time.Hour).Unix())),
+		"no exp":    key.sign(t, claims("exp", nil)),
+		"not yet":   key.sign(t, claims("nbf", time.Now().Add(time.Hour).Unix())),
+		"issuer":    key.sign(t, claims("iss", "https://other.test")),
+		"audience":  key.sign(t, claims("aud", "other")),
+		"signature": other.sign(t, validClaims()),
+		"malformed": "not.a.token",
+		"none": base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+			base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice"}`)) + ".",
+	}
+
+	is := assert.New(t)
+	for name, token := range tokens {
+		_, err := a.Authenticate(bearer(token))
+		is.ErrorIs(err, ErrInvalidCredentials, name)
+	}
+}
+
+func TestJWKSRotation(t *testing.T) {
+	old := newTestKey(t, "old", "ES256")
+	next := newTestKey(t, "new", "ES256")
+	jwks := &testJWKS{}
+	jwks.set(old)
+	srv := httptest.NewServer(jwks)
+	defer srv.Close()
+
+	a := NewJWT(NewJWKS(srv.URL, WithJWKSMinRefresh(0)))
+
+	is := assert.New(t)
+	_, err := a.Authenticate(bearer(old.sign(t, validClaims())))
+	is.NoError(err)
+
+	jwks.set(old, next)
+	_, err = a.Authenticate(bearer(next.sign(t, validClaims())))
+	is.NoError(err)
+	is.Equal(int32(2), jwks.fetches.Load())
+
+	_, err = a.Authenticate(bearer(old.sign(t, validClaims())))
+	is.NoError(err)
+	is.Equal(int32(2), jwks.fetches.Load())
+}
+
+func TestJWKSUnreachable(t *testing.T) {
+	key := newTestKey(t, "rs", "RS256")
+	jwks := &testJWKS{}
+	jwks.set(key)
+	srv := httptest.NewServer(jwks)
+
+	s := NewJWKS(srv.URL, WithJWKSRefresh(0), WithJWKSMinRefresh(0))
+	a := NewJWT(s)
+
+	is := assert.New(t)
+	_, err := a.Authenticate(bearer(key.sign(t, validClaims())))
+	is.NoError(err)
+
+	// Stale keys are kept.
+	srv.Close()
+	_, err = a.Authenticate(bearer(key.sign(t, validClaims())))
+	is.NoError(err)
+
+	_, err = a.Authenticate(bearer(newTestKey(t, "other", "RS256").sign(t, validClaims())))
+	is.Error(err)
+	is.NotErrorIs(err, ErrInvalidCredentials)
+}
+
+// slowJWKS delays the responses of a testJWKS while delay is set.
+type slowJWKS struct {
+	*testJWKS
+	delay atomic.Int64
+}
+
+func (s *slowJWKS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.testJWKS.ServeHTTP(w, r)
+	time.Sleep(time.Duration(s.delay.Load()))
+}
+
+func TestJWKSRequestContext(t *testing.T) {
+	key := newTestKey(t, "es", "ES256")
+	jwks := &slowJWKS{testJWKS: &testJWKS{}}
+	jwks.set(key)
+	jwks.delay.Store(int64(200 * time.Millisecond))
+	srv := httptest.NewServer(jwks)
+	defer srv.Close()
+
+	s := NewJWKS(srv.URL, WithJWKSClient(&http.Client{Timeout: 50 * time.Millisecond}))
+
+	is := assert.New(t)
+
+	// Timeouts are remembered for the minimum refresh interval.
+	_, err := s.Key(context.Background(), "es")
+	is.ErrorIs(err, context.DeadlineExceeded)
+	jwks.delay.Store(0)
+	_, err = s.Key(context.Background(), "es")
+	is.ErrorIs(err, context.DeadlineExceeded)
+	is.Equal(int32(1), jwks.fetches.Load())
+
+	// A cancelled request does not cancel the fetch.
+	s = NewJWKS(srv.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = s.Key(ctx, "es")
+	_, err = s.Key(context.Background(), "es")
+	is.NoError(err)
+	is.Equal(int32(2), jwks.fetches.Load())
+}
+
+func TestJWKSSharedFetch(t *testing.T) {
+	key := newTestKey(t, "es", "ES256")
+	jwks := &slowJWKS{testJWKS: &testJWKS{}}
+	jwks.set(key)
+	jwks.delay.Store(int64(100 * time.Millisecond))
+	srv := httptest.NewServer(jwks)
+	defer srv.Close()
+
+	s := NewJWKS(srv.URL, WithJWKSMinRefresh(0))
+
+	is := assert.New(t)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Key(context.Background(), "es")
+			is.NoError(err)
+		}()
+	}
+	wg.Wait()
+	is.Equal(int32(1), jwks.fetches.Load())
+}
+
+func TestJWKSBackgroundRefresh(t *testing.T) {
+	key := newTestKey(t, "es", "ES256")
+	jwks := &slowJWKS{testJWKS: &testJWKS{}}
+	jwks.set(key)
+	srv := httptest.NewServer(jwks)
+	defer srv.Close()
+
+	s := NewJWKS(srv.URL, WithJWKSRefresh(0), WithJWKSMinRefresh(0))
+
+	is := assert.New(t)
+	_, err := s.Key(context.Background(), "es")
+	is.NoError(err)
+
+	// Expired keys are served without waiting for the refresh.
+	jwks.delay.Store(int64(time.Second))
+	start := time.Now()
+	_, err = s.Key(context.Background(), "es")
+	is.NoError(err)
+	is.Less(time.Since(start), 500*time.Millisecond)
+	is.Eventually(func() bool { return jwks.fetches.Load() == 2 }, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+)
+
+// mtlsOption applies a configuration mtlsOption value to MTLS.
+type mtlsOption interface{ apply(*MTLS) }
+
+// mtlsOptionFunc applies a set of options to a config.
+type mtlsOptionFunc func(*MTLS)
+
+// apply returns a config with option(s) applied.
+func (o mtlsOptionFunc) apply(conf *MTLS) { o(conf) }
+
+// WithMTLSPrincipal sets how the principal is built from the client
+// certificate, such as mapping its organizational units to roles. Returning
+// an error or a nil principal rejects the certificate.
+func WithMTLSPrincipal(fn func(cert *x509.Certificate) (*Principal, error)) mtlsOption {
+	return mtlsOptionFunc(func(conf *MTLS) {
+		conf.principal = fn
+	})
+}
+
+// MTLS authenticates requests by the client certificate of the connection,
+// verified by the server, see the http.tls.client.* config of httprouter. By
+// default the subject is the first URI SAN, such as a SPIFFE ID, or else the
+// common name.
+type MTLS struct {
+	principal func(cert *x509.Certificate) (*Principal, error)
+}
+
+// NewMTLS returns an Authenticator reading the client certificate.
+func NewMTLS(opts ...mtlsOption) *MTLS {
+	m := &MTLS{principal: certPrincipal}
+	for _, opt := range opts {
+		opt.apply(m)
+	}
+	return m
+}
+
+func (m *MTLS) Authenticate(r *http.Request) (*Principal, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil, ErrNoCredentials
+	}
+	// Certificates are only verified with tls.VerifyClientCertIfGiven or
+	// tls.RequireAndVerifyClientCert.
+	if len(r.TLS.VerifiedChains) == 0 {
+		return nil, fmt.Errorf("%w: unverified client certificate", ErrInvalidCredentials)
+	}
+
+	cert := r.TLS.VerifiedChains[0][0]
+	p, err := m.principal(cert)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+	if p == nil {
+		return nil, fmt.Errorf("%w: no principal for certificate", ErrInvalidCredentials)
+	}
+
+	p.Method = "mtls"
+	p.Certificate = cert
+	return p, nil
+}
+
+func certPrincipal(cert *x509.Certificate) (*Principal, error) {
+	p := &Principal{Subject: cert.Subject.CommonName}
+	if len(cert.URIs) > 0 {
+		p.Subject = cert.URIs[0].String()
+	}
+	return p, nil
+}
+
+var _ Authenticator = (*MTLS)(nil)
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMTLS(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://cluster.local/ns/default/sa/billing")
+	cert := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "billing", OrganizationalUnit: []string{"finance"}},
+		URIs:    []*url.URL{spiffe},
+	}
+
+	request := func(verified bool) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		if verified {
+			r.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+		}
+		return r
+	}
+
+	is := assert.New(t)
+
+	p, err := NewMTLS().Authenticate(request(true))
+	if is.NoError(err) {
+		is.Equal(spiffe.String(), p.Subject)
+		is.Equal("mtls", p.Method)
+		is.Same(cert, p.Certificate)
+	}
+
+	_, err = NewMTLS().Authenticate(request(false))
+	is.ErrorIs(err, ErrInvalidCredentials)
+
+	_, err = NewMTLS().Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	is.ErrorIs(err, ErrNoCredentials)
+
+	a := NewMTLS(WithMTLSPrincipal(func(cert *x509.Certificate) (*Principal, error) {
+		if len(cert.Subject.OrganizationalUnit) == 0 {
+			return nil, errors.New("no organizational unit")
+		}
+		return &Principal{Subject: cert.Subject.CommonName, Roles: cert.Subject.OrganizationalUnit}, nil
+	}))
+	p, err = a.Authenticate(request(true))
+	if is.NoError(err) {
+		is.Equal("billing", p.Subject)
+		is.Equal([]string{"finance"}, p.Roles)
+	}
+
+	nilPrincipal := NewMTLS(WithMTLSPrincipal(func(*x509.Certificate) (*Principal, error) { return nil, nil }))
+	_, err = nilPrincipal.Authenticate(request(true))
+	is.ErrorIs(err, ErrInvalidCredentials)
+}
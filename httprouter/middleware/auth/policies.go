@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// anyMethods are the methods registered by gin.IRoutes.Any.
+var anyMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodHead,
+	http.MethodOptions, http.MethodDelete, http.MethodConnect, http.MethodTrace,
+}
+
+// RoutePolicy is a route with its effective policy, nil when unprotected.
+type RoutePolicy struct {
+	Method string
+	Path   string
+	Policy Policy
+}
+
+// Policies attaches policies to the routes of an httprouter.Router and
+// records them, so the coverage can be audited with Routes.
+//
+//	policies := auth.NewPolicies()
+//	api := policies.Protect(router.Group("/api"), auth.Authenticated())
+//	api.GET("/me", me)
+//	api.Require(auth.RequireScopes("orders:write")).POST("/orders", createOrder)
+//	admin := api.Group("/admin").Require(auth.RequireRoles("admin"))
+type Policies struct {
+	mu     sync.Mutex
+	routes map[[2]string]Policy
+}
+
+func NewPolicies() *Policies {
+	return &Policies{routes: map[[2]string]Policy{}}
+}
+
+// Protect returns a group registering its routes on r behind policy, see
+// Require.
+func (ps *Policies) Protect(r gin.IRouter, policy Policy) *Group {
+	return &Group{policies: ps, group: r.Group("", Require(policy)), policy: policy}
+}
+
+// Routes returns routes, such as httprouter.Server.Routes, with their
+// effective policy, sorted by path and method.
+func (ps *Policies) Routes(routes gin.RoutesInfo) []RoutePolicy {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	rps := make([]RoutePolicy, 0, len(routes))
+	for _, r := range routes {
+		rps = append(rps, RoutePolicy{Method: r.Method, Path: r.Path, Policy: ps.routes[[2]string{r.Method, r.Path}]})
+	}
+
+	slices.SortFunc(rps, func(a, b RoutePolicy) int {
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+	return rps
+}
+
+func (ps *Policies) record(methods []string, path string, policy Policy) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for _, m := range methods {
+		ps.routes[[2]string{m, path}] = policy
+	}
+}
+
+// Group is a gin.RouterGroup whose routes require a policy, see
+// Policies.Protect.
+type Group struct {
+	policies *Policies
+	group    *gin.RouterGroup
+	policy   Policy
+}
+
+// Group returns a group of routes under relativePath requiring the same
+// policy.
+func (g *Group) Group(relativePath string, handlers ...gin.HandlerFunc) *Group {
+	return &Group{policies: g.policies, group: g.group.Group(relativePath, handlers...), policy: g.policy}
+}
+
+// Require returns a group of the same routes also requiring policy.
+func (g *Group) Require(policy Policy) *Group {
+	return &Group{policies: g.policies, group: g.group.Group("", Require(policy)), policy: AllOf(g.policy, policy)}
+}
+
+// Policy returns the effective policy of the group.
+func (g *Group) Policy() Policy { return g.policy }
+
+func (g *Group) BasePath() string { return g.group.BasePath() }
+
+func (g *Group) record(methods []string, relativePath string) gin.IRoutes {
+	p := path.Join(g.group.BasePath(), relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	g.policies.record(methods, p, g.policy)
+	return g
+}
+
+func (g *Group) Use(middleware ...gin.HandlerFunc) gin.IRoutes {
+	g.group.Use(middleware...)
+	return g
+}
+
+func (g *Group) Handle(method, relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	g.group.Handle(method, relativePath, handlers...)
+	return g.record([]string{method}, relativePath)
+}
+
+func (g *Group) Any(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	g.group.Any(relativePath, handlers...)
+	return g.record(anyMethods, relativePath)
+}
+
+func (g *Group) GET(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodGet, relativePath, handlers...)
+}
+
+func (g *Group) POST(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodPost, relativePath, handlers...)
+}
+
+func (g *Group) DELETE(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodDelete, relativePath, handlers...)
+}
+
+func (g *Group) PATCH(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodPatch, relativePath, handlers...)
+}
+
+func (g *Group) PUT(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodPut, relativePath, handlers...)
+}
+
+func (g *Group) OPTIONS(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodOptions, relativePath, handlers...)
+}
+
+func (g *Group) HEAD(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodHead, relativePath, handlers...)
+}
+
+func (g *Group) Match(methods []string, relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	g.group.Match(methods, relativePath, handlers...)
+	return g.record(methods, relativePath)
+}
+
+func (g *Group) StaticFile(relativePath, filepath string) gin.IRoutes {
+	g.group.StaticFile(relativePath, filepath)
+	return g.record([]string{http.MethodGet, http.MethodHead}, relativePath)
+}
+
+func (g *Group) StaticFileFS(relativePath, filepath string, fs http.FileSystem) gin.IRoutes {
+	g.group.StaticFileFS(relativePath, filepath, fs)
+	return g.record([]string{http.MethodGet, http.MethodHead}, relativePath)
+}
+
+func (g *Group) Static(relativePath, root string) gin.IRoutes {
+	g.group.Static(relativePath, root)
+	return g.record([]string{http.MethodGet, http.MethodHead}, path.Join(relativePath, "/*filepath"))
+}
+
+func (g *Group) StaticFS(relativePath string, fs http.FileSystem) gin.IRoutes {
+	g.group.StaticFS(relativePath, fs)
+	return g.record([]string{http.MethodGet, http.MethodHead}, path.Join(relativePath, "/*filepath"))
+}
+
+var _ gin.IRoutes = (*Group)(nil)
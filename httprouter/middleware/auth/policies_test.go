@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoliciesRoutes(t *testing.T) {
+	keys, _ := NewAPIKeys(
+		WithAPIKey("admin", Principal{Subject: "root", Roles: []string{"admin"}, Scopes: []string{"orders:write"}}),
+		WithAPIKey("user", Principal{Subject: "alice"}),
+	)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Authenticate(keys, WithOptional()))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	policies := NewPolicies()
+	router.GET("/health", ok)
+
+	api := policies.Protect(router.Group("/api"), Authenticated())
+	api.GET("/me", ok)
+	api.Require(RequireScopes("orders:write")).POST("/orders", ok)
+
+	admin := api.Group("/admin").Require(RequireRoles("admin"))
+	admin.DELETE("/users/:id", ok)
+
+	serve := func(method, path, key string) int {
+		r := httptest.NewRequest(method, path, nil)
+		if key != "" {
+			r.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	is := assert.New(t)
+	is.Equal(http.StatusOK, serve(http.MethodGet, "/health", ""))
+	is.Equal(http.StatusUnauthorized, serve(http.MethodGet, "/api/me", ""))
+	is.Equal(http.StatusOK, serve(http.MethodGet, "/api/me", "user"))
+	is.Equal(http.StatusForbidden, serve(http.MethodPost, "/api/orders", "user"))
+	is.Equal(http.StatusOK, serve(http.MethodPost, "/api/orders", "admin"))
+	is.Equal(http.StatusForbidden, serve(http.MethodDelete, "/api/admin/users/1", "user"))
+	is.Equal(http.StatusOK, serve(http.MethodDelete, "/api/admin/users/1", "admin"))
+
+	routes := map[string]string{}
+	for _, r := range policies.Routes(router.Routes()) {
+		routes[r.Method+" "+r.Path] = "none"
+		if r.Policy != nil {
+			routes[r.Method+" "+r.Path] = r.Policy.String()
+		}
+	}
+	is.Equal(map[string]string{
+		"GET /health":                 "none",
+		"GET /api/me":                 "authenticated",
+		"POST /api/orders":            "(authenticated & scopes(orders:write))",
+		"DELETE /api/admin/users/:id": "(authenticated & roles(admin))",
+	}, routes)
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/cyg-pd/go-core/httprouter/render"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrForbidden is rendered when a policy denies a request.
+var ErrForbidden = errors.New(pkg + ": forbidden")
+
+// Policy decides whether the principal of a request may access it. String
+// describes the policy, see Policies.Routes.
+type Policy interface {
+	Allow(c *gin.Context, p *Principal) bool
+	String() string
+}
+
+type policyFunc struct {
+	name  string
+	allow func(c *gin.Context, p *Principal) bool
+}
+
+func (f policyFunc) Allow(c *gin.Context, p *Principal) bool { return f.allow(c, p) }
+func (f policyFunc) String() string                          { return f.name }
+
+// Authenticated allows any principal.
+func Authenticated() Policy {
+	return policyFunc{"authenticated", func(*gin.Context, *Principal) bool { return true }}
+}
+
+// RequireScopes allows the principals holding every one of scopes.
+func RequireScopes(scopes ...string) Policy {
+	return policyFunc{"scopes(" + strings.Join(scopes, ", ") + ")", func(_ *gin.Context, p *Principal) bool {
+		for _, s := range scopes {
+			if !p.HasScope(s) {
+				return false
+			}
+		}
+		return true
+	}}
+}
+
+// RequireRoles allows the principals holding any one of roles.
+func RequireRoles(roles ...string) Policy {
+	return policyFunc{"roles(" + strings.Join(roles, " | ") + ")", func(_ *gin.Context, p *Principal) bool {
+		return slices.ContainsFunc(roles, p.HasRole)
+	}}
+}
+
+// RequirePredicate allows the requests for which allow returns true, such as
+// a principal accessing its own resources. name describes the predicate.
+func RequirePredicate(name string, allow func(c *gin.Context, p *Principal) bool) Policy {
+	return policyFunc{name, allow}
+}
+
+// AllOf allows the requests allowed by every one of policies.
+func AllOf(policies ...Policy) Policy {
+	return policyFunc{joinPolicies(policies, " & "), func(c *gin.Context, p *Principal) bool {
+		for _, policy := range policies {
+			if !policy.Allow(c, p) {
+				return false
+			}
+		}
+		return true
+	}}
+}
+
+// AnyOf allows the requests allowed by any one of policies.
+func AnyOf(policies ...Policy) Policy {
+	return policyFunc{joinPolicies(policies, " | "), func(c *gin.Context, p *Principal) bool {
+		for _, policy := range policies {
+			if policy.Allow(c, p) {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+func joinPolicies(policies []Policy, sep string) string {
+	s := make([]string, len(policies))
+	for i, p := range policies {
+		s[i] = p.String()
+	}
+	return "(" + strings.Join(s, sep) + ")"
+}
+
+// Require lets through the requests allowed by policy. It must run after
+// Authenticate: requests without a principal are answered with 401, denied
+// ones with 403, and logged on the "authz" channel.
+func Require(policy Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := FromContext(c)
+		switch {
+		case !ok || p == nil:
+			deny(c, policy, nil, &render.StatusError{Code: http.StatusUnauthorized, Err: ErrNoCredentials})
+		case !policy.Allow(c, p):
+			deny(c, policy, p, &render.StatusError{Code: http.StatusForbidden, Err: ErrForbidden})
+		}
+	}
+}
+
+func deny(c *gin.Context, policy Policy, p *Principal, err *render.StatusError) {
+	principal := slog.Attr{}
+	if p != nil {
+		principal = slog.Group("principal",
+			slog.String("subject", p.Subject),
+			slog.String("method", p.Method),
+		)
+	}
+
+	slog.Log(
+		context.WithoutCancel(c.Request.Context()),
+		slog.LevelWarn,
+		c.Request.Method+" "+c.Request.URL.Path+" "+c.Request.Proto,
+		slog.String("package", pkg),
+		slog.String("channel", "authz"),
+		slog.String("ip", c.ClientIP()),
+		slog.String("route", c.FullPath()),
+		slog.String("policy", policy.String()),
+		slog.Int("status_code", err.Code),
+		principal,
+	)
+
+	render.RenderError(c, err)
+	c.Abort()
+}
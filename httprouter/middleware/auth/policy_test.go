@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicies(t *testing.T) {
+	is := assert.New(t)
+
+	owner := RequirePredicate("owner", func(c *gin.Context, p *Principal) bool {
+		return c.Param("id") == p.Subject
+	})
+	cases := []struct {
+		policy Policy
+		p      *Principal
+		allow  bool
+	}{
+		{Authenticated(), &Principal{}, true},
+		{RequireScopes("read", "write"), &Principal{Scopes: []string{"read", "write"}}, true},
+		{RequireScopes("read", "write"), &Principal{Scopes: []string{"read"}}, false},
+		{RequireRoles("admin", "ops"), &Principal{Roles: []string{"ops"}}, true},
+		{RequireRoles("admin", "ops"), &Principal{Roles: []string{"dev"}}, false},
+		{owner, &Principal{Subject: "alice"}, true},
+		{owner, &Principal{Subject: "bob"}, false},
+		{AnyOf(owner, RequireRoles("admin")), &Principal{Subject: "bob", Roles: []string{"admin"}}, true},
+		{AllOf(owner, RequireRoles("admin")), &Principal{Subject: "bob", Roles: []string{"admin"}}, false},
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Params = gin.Params{{Key: "id", Value: "alice"}}
+	for _, tc := range cases {
+		is.Equal(tc.allow, tc.policy.Allow(c, tc.p), tc.policy.String())
+	}
+
+	is.Equal("(owner | roles(admin))", AnyOf(owner, RequireRoles("admin")).String())
+}
+
+func TestRequire(t *testing.T) {
+	buf := &bytes.Buffer{}
+	defer func(l *slog.Logger) { slog.SetDefault(l) }(slog.Default())
+	slog.SetDefault(slog.New(slog.NewJSONHandler(buf, nil)))
+
+	keys, _ := NewAPIKeys(
+		WithAPIKey("admin", Principal{Subject: "root", Roles: []string{"admin"}}),
+		WithAPIKey("user", Principal{Subject: "alice"}),
+	)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Authenticate(keys, WithOptional()))
+	router.GET("/admin", Require(RequireRoles("admin")), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(key string) int {
+		r := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		if key != "" {
+			r.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	is := assert.New(t)
+	is.Equal(http.StatusOK, serve("admin"))
+	is.Equal(http.StatusUnauthorized, serve(""))
+
+	buf.Reset()
+	is.Equal(http.StatusForbidden, serve("user"))
+
+	var log map[string]any
+	is.NoError(json.Unmarshal(buf.Bytes(), &log))
+	is.Equal("authz", log["channel"])
+	is.Equal("/admin", log["route"])
+	is.Equal("roles(admin)", log["policy"])
+	is.Equal(float64(http.StatusForbidden), log["status_code"])
+	is.Equal(map[string]any{"subject": "alice", "method": "api_key"}, log["principal"])
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cyg-pd/go-core/httprouter/render"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrBodyTooLarge = errors.New(pkg + ": request body too large")
+	ErrBodyTooSlow  = errors.New(pkg + ": request body too slow")
+)
+
+// bodyLimitOption applies a configuration bodyLimitOption value to a BodyLimit.
+type bodyLimitOption interface{ apply(*bodyLimit) }
+
+// bodyLimitOptionFunc applies a set of options to a config.
+type bodyLimitOptionFunc func(*bodyLimit)
+
+// apply returns a config with option(s) applied.
+func (o bodyLimitOptionFunc) apply(conf *bodyLimit) { o(conf) }
+
+// WithBodyLimitRoute overrides the limit for the route, as registered on the
+// router such as "/files/:id". A limit of 0 or less removes it.
+func WithBodyLimitRoute(route string, limit int64) bodyLimitOption {
+	return bodyLimitOptionFunc(func(conf *bodyLimit) {
+		conf.routes[route] = limit
+	})
+}
+
+// WithBodyLimitMinRate requires clients to send bodies at rate bytes per
+// second on average, after a grace period, so slow clients do not hold
+// connections. Reading is cut off with a 408 otherwise.
+func WithBodyLimitMinRate(rate int64, grace time.Duration) bodyLimitOption {
+	return bodyLimitOptionFunc(func(conf *bodyLimit) {
+		conf.minRate, conf.grace = rate, grace
+	})
+}
+
+type bodyLimit struct {
+	limit   int64
+	routes  map[string]int64
+	minRate int64
+	grace   time.Duration
+}
+
+// BodyLimit caps request bodies at limit bytes, answering 413 through
+// render.RenderError. Bodies announcing a larger Content-Length are rejected
+// before reading; others, such as chunked ones, once the limit is reached, if
+// the handler did not answer. A limit of 0 or less removes it.
+func BodyLimit(limit int64, opts ...bodyLimitOption) gin.HandlerFunc {
+	b := &bodyLimit{limit: limit, routes: map[string]int64{}}
+	for _, opt := range opts {
+		opt.apply(b)
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			return
+		}
+
+		limit := b.limit
+		if l, ok := b.routes[c.FullPath()]; ok {
+			limit = l
+		}
+
+		if limit > 0 && c.Request.ContentLength > limit {
+			render.RenderError(c, &render.StatusError{Code: http.StatusRequestEntityTooLarge, Err: ErrBodyTooLarge})
+			c.Abort()
+			return
+		}
+
+		r := &limitedBody{ReadCloser: c.Request.Body, limit: limit}
+		if b.minRate > 0 {
+			r.rc = http.NewResponseController(c.Writer)
+			r.minRate, r.start, r.grace = b.minRate, time.Now(), b.grace
+		}
+		c.Request.Body = r
+
+		c.Next()
+
+		if c.Writer.Written() {
+			return
+		}
+		switch {
+		case r.tooLarge:
+			render.RenderError(c, &render.StatusError{Code: http.StatusRequestEntityTooLarge, Err: ErrBodyTooLarge})
+		case r.tooSlow:
+			render.RenderError(c, &render.StatusError{Code: http.StatusRequestTimeout, Err: ErrBodyTooSlow})
+		}
+	}
+}
+
+// limitedBody fails reads past limit, and extends the read deadline as bytes
+// arrive when minRate is set.
+type limitedBody struct {
+	io.ReadCloser
+	limit int64
+	read  int64
+
+	rc      *http.ResponseController
+	minRate int64
+	start   time.Time
+	grace   time.Duration
+
+	tooLarge, tooSlow bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.limit > 0 {
+		if b.read > b.limit {
+			b.tooLarge = true
+			return 0, ErrBodyTooLarge
+		}
+		// Read one byte past the limit to tell an exact fit from an overflow.
+		if rest := b.limit - b.read + 1; int64(len(p)) > rest {
+			p = p[:rest]
+		}
+	}
+
+	if b.rc != nil {
+		// The next byte is due once the average rate would drop below minRate.
+		due := b.start.Add(b.grace + time.Duration(float64(b.read+1)/float64(b.minRate)*float64(time.Second)))
+		_ = b.rc.SetReadDeadline(due)
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		b.tooSlow = true
+		return n, ErrBodyTooSlow
+	}
+	if b.limit > 0 && b.read > b.limit {
+		b.tooLarge = true
+		return n - int(b.read-b.limit), ErrBodyTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func createBodyLimitRouter(opts ...bodyLimitOption) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(BodyLimit(8, opts...))
+
+	echo := func(c *gin.Context) {
+		b, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		c.String(http.StatusOK, string(b))
+	}
+	router.POST("/echo", echo)
+	router.POST("/upload", echo)
+	return router
+}
+
+func TestBodyLimit(t *testing.T) {
+	router := createBodyLimitRouter(WithBodyLimitRoute("/upload", 64))
+
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		chunked bool
+		code    int
+	}{
+		{"within limit", "/echo", "12345678", false, http.StatusOK},
+		{"content length", "/echo", "123456789", false, http.StatusRequestEntityTooLarge},
+		{"chunked", "/echo", "123456789", true, http.StatusRequestEntityTooLarge},
+		{"route override", "/upload", strings.Repeat("x", 64), true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			is := assert.New(t)
+			is.Equal(tt.code, w.Code)
+			if tt.code == http.StatusOK {
+				is.Equal(tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBodyLimitMinRate(t *testing.T) {
+	srv := httptest.NewServer(createBodyLimitRouter(WithBodyLimitMinRate(1000, 50*time.Millisecond)))
+	defer srv.Close()
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go func() { _, _ = pw.Write([]byte("1")) }()
+
+	res, err := http.Post(srv.URL+"/echo", "text/plain", pr)
+
+	is := assert.New(t)
+	if is.NoError(err) {
+		_ = res.Body.Close()
+		is.Equal(http.StatusRequestTimeout, res.StatusCode)
+	}
+}
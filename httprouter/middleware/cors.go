@@ -0,0 +1,233 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cyg-pd/go-core/config"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// CORSPolicy is the CORS policy of a group of routes. Policies without
+// allowed origins add no CORS headers.
+type CORSPolicy struct {
+	// AllowedOrigins lists the origins allowed, such as
+	// "https://app.example.com", "https://*.example.com" for any subdomain or
+	// "*" for any origin.
+	AllowedOrigins []string
+	// AllowedMethods defaults to GET, HEAD, POST, PUT, PATCH and DELETE.
+	AllowedMethods []string
+	// AllowedHeaders lists the request headers allowed, "*" allowing any.
+	AllowedHeaders []string
+	ExposedHeaders []string
+	// AllowCredentials is ignored, and logged, when any origin is allowed:
+	// any site could otherwise read credentialed responses.
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// corsPolicyFromConfig reads a policy from the allowed.origins,
+// allowed.methods, allowed.headers, exposed.headers, allow.credentials and
+// max.age keys of v under prefix.
+func corsPolicyFromConfig(v *viper.Viper, prefix string) CORSPolicy {
+	return CORSPolicy{
+		AllowedOrigins:   v.GetStringSlice(prefix + "allowed.origins"),
+		AllowedMethods:   v.GetStringSlice(prefix + "allowed.methods"),
+		AllowedHeaders:   v.GetStringSlice(prefix + "allowed.headers"),
+		ExposedHeaders:   v.GetStringSlice(prefix + "exposed.headers"),
+		AllowCredentials: v.GetBool(prefix + "allow.credentials"),
+		MaxAge:           v.GetDuration(prefix + "max.age"),
+	}
+}
+
+// corsGroup is a policy applied to the requests whose path is under prefix,
+// matching whole path segments.
+type corsGroup struct {
+	Prefix string
+	CORSPolicy
+}
+
+var defaultCORSMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost,
+	http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// corsOption applies a configuration corsOption value to a CORS.
+type corsOption interface{ apply(*cors) }
+
+// corsOptionFunc applies a set of options to a config.
+type corsOptionFunc func(*cors)
+
+// apply returns a config with option(s) applied.
+func (o corsOptionFunc) apply(conf *cors) { o(conf) }
+
+// WithCORSPolicy sets the policy of the routes under prefix, such as /api for
+// /api/items but not /apiv2, overriding the configured ones. The longest
+// prefix applies.
+func WithCORSPolicy(prefix string, policy CORSPolicy) corsOption {
+	return corsOptionFunc(func(conf *cors) {
+		conf.groups = append(conf.groups, corsGroup{Prefix: prefix, CORSPolicy: policy})
+	})
+}
+
+type cors struct {
+	groups []corsGroup
+}
+
+// CORS answers CORS preflight requests and adds the CORS headers to the
+// responses, following the policy read from the http.cors.* keys of v, such
+// as http.cors.allowed.origins, see httprouter.SetupFlags. The entries of the
+// http.cors.groups list set the policy of the routes under their prefix key,
+// with the same keys. Malformed groups are logged and skipped.
+//
+// It must be used on the engine, not on a route group: preflight OPTIONS
+// requests match no route, since DisableGeneralOptionsHandler is set.
+func CORS(v *viper.Viper, opts ...corsOption) gin.HandlerFunc {
+	c := &cors{}
+	c.groups = append(c.groups, corsGroup{CORSPolicy: corsPolicyFromConfig(v, "http.cors.")})
+
+	groups, err := config.SubList(v, "http.cors.groups")
+	if err != nil {
+		slog.Error(pkg + ": CORS groups: " + err.Error())
+	}
+	for _, g := range groups {
+		c.groups = append(c.groups, corsGroup{Prefix: g.GetString("prefix"), CORSPolicy: corsPolicyFromConfig(g, "")})
+	}
+
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+
+	// The longest prefix applies; among equal ones, the last added, so
+	// options override the configuration.
+	slices.Reverse(c.groups)
+	slices.SortStableFunc(c.groups, func(a, b corsGroup) int { return len(b.Prefix) - len(a.Prefix) })
+	for i := range c.groups {
+		p := &c.groups[i].CORSPolicy
+		if len(p.AllowedMethods) == 0 {
+			p.AllowedMethods = defaultCORSMethods
+		}
+		if p.AllowCredentials && slices.Contains(p.AllowedOrigins, "*") {
+			slog.Error(pkg + ": CORS policy of " + strconv.Quote(c.groups[i].Prefix) + " allows any origin, credentials are not allowed")
+			p.AllowCredentials = false
+		}
+	}
+
+	return c.handle
+}
+
+func (c *cors) policy(path string) *CORSPolicy {
+	for i, g := range c.groups {
+		if hasPathPrefix(path, g.Prefix) {
+			return &c.groups[i].CORSPolicy
+		}
+	}
+	return nil
+}
+
+// hasPathPrefix reports whether path is prefix or below it.
+func hasPathPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+func (c *cors) handle(ctx *gin.Context) {
+	origin := ctx.GetHeader("Origin")
+	if origin == "" {
+		return
+	}
+
+	p := c.policy(ctx.Request.URL.Path)
+	if p == nil || len(p.AllowedOrigins) == 0 {
+		return
+	}
+
+	h := ctx.Writer.Header()
+	h.Add("Vary", "Origin")
+
+	preflight := ctx.Request.Method == http.MethodOptions && ctx.GetHeader("Access-Control-Request-Method") != ""
+	if !p.allowsOrigin(origin) {
+		if preflight {
+			ctx.AbortWithStatus(http.StatusForbidden)
+		}
+		return
+	}
+
+	if slices.Contains(p.AllowedOrigins, "*") {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if !preflight {
+		if len(p.ExposedHeaders) > 0 {
+			h.Set("Access-Control-Expose-Headers", strings.Join(p.ExposedHeaders, ", "))
+		}
+		return
+	}
+
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	method := strings.ToUpper(ctx.GetHeader("Access-Control-Request-Method"))
+	if !slices.Contains(p.AllowedMethods, method) {
+		ctx.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+	h.Set("Access-Control-Allow-Methods", strings.Join(p.AllowedMethods, ", "))
+
+	if requested := ctx.GetHeader("Access-Control-Request-Headers"); requested != "" {
+		if !p.allowsHeaders(requested) {
+			ctx.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		h.Set("Access-Control-Allow-Headers", requested)
+	}
+
+	if p.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge.Seconds())))
+	}
+
+	ctx.AbortWithStatus(http.StatusNoContent)
+}
+
+func (p *CORSPolicy) allowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range p.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		switch {
+		case allowed == "*", allowed == origin:
+			return true
+		case strings.Contains(allowed, "://*."):
+			// "https://*.example.com" matches "https://app.example.com" but
+			// not "https://example.com".
+			scheme, domain, _ := strings.Cut(allowed, "*")
+			if strings.HasPrefix(origin, scheme) && strings.HasSuffix(origin, domain) &&
+				len(origin) > len(scheme)+len(domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *CORSPolicy) allowsHeaders(requested string) bool {
+	if slices.Contains(p.AllowedHeaders, "*") {
+		return true
+	}
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !slices.ContainsFunc(p.AllowedHeaders, func(h string) bool { return strings.EqualFold(h, name) }) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func createCORSRouter() *gin.Engine {
+	v := viper.New()
+	v.Set("http.cors.allowed.origins", []string{"https://*.example.com"})
+	v.Set("http.cors.allowed.headers", []string{"Content-Type"})
+	v.Set("http.cors.exposed.headers", []string{"X-Request-Id"})
+	v.Set("http.cors.max.age", "10m")
+	v.Set("http.cors.groups", []map[string]any{
+		{"prefix": "/public", "allowed": map[string]any{"origins": []string{"*"}}},
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORS(v, WithCORSPolicy("/admin", CORSPolicy{
+		AllowedOrigins:   []string{"https://admin.example.org"},
+		AllowCredentials: true,
+		MaxAge:           time.Minute,
+	})))
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/api/items", ok)
+	router.GET("/public/items", ok)
+	router.DELETE("/admin/items", ok)
+	return router
+}
+
+func TestCORS(t *testing.T) {
+	router := createCORSRouter()
+
+	tests := []struct {
+		name           string
+		method, path   string
+		origin         string
+		requestMethod  string
+		requestHeaders string
+		code           int
+		headers        map[string]string
+	}{
+		{
+			name: "no origin", method: http.MethodGet, path: "/api/items",
+			code:    http.StatusOK,
+			headers: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name: "wildcard subdomain", method: http.MethodGet, path: "/api/items", origin: "https://app.example.com",
+			code: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":   "https://app.example.com",
+				"Access-Control-Expose-Headers": "X-Request-Id",
+				"Vary":                          "Origin",
+			},
+		},
+		{
+			name: "bare domain", method: http.MethodGet, path: "/api/items", origin: "https://example.com",
+			code:    http.StatusOK,
+			headers: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name: "preflight", method: http.MethodOptions, path: "/api/items", origin: "https://app.example.com",
+			requestMethod: http.MethodPost, requestHeaders: "content-type",
+			code: http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "GET, HEAD, POST, PUT, PATCH, DELETE",
+				"Access-Control-Allow-Headers": "content-type",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name: "preflight disallowed header", method: http.MethodOptions, path: "/api/items", origin: "https://app.example.com",
+			requestMethod: http.MethodPost, requestHeaders: "Authorization",
+			code: http.StatusForbidden,
+		},
+		{
+			name: "preflight disallowed origin", method: http.MethodOptions, path: "/api/items", origin: "https://evil.com",
+			requestMethod: http.MethodGet,
+			code:          http.StatusForbidden,
+		},
+		{
+			name: "configured group", method: http.MethodGet, path: "/public/items", origin: "https://evil.com",
+			code:    http.StatusOK,
+			headers: map[string]string{"Access-Control-Allow-Origin": "*"},
+		},
+		{
+			name: "group prefix segment", method: http.MethodGet, path: "/publicity", origin: "https://evil.com",
+			code:    http.StatusNotFound,
+			headers: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name: "credentials", method: http.MethodOptions, path: "/admin/items", origin: "https://admin.example.org",
+			requestMethod: http.MethodDelete,
+			code:          http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":      "https://admin.example.org",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Max-Age":           "60",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+			if tt.requestHeaders != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.requestHeaders)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			is := assert.New(t)
+			is.Equal(tt.code, w.Code)
+			for k, v := range tt.headers {
+				is.Equal(v, w.Header().Get(k), k)
+			}
+		})
+	}
+}
+
+func TestCORSMalformedGroups(t *testing.T) {
+	buf := &bytes.Buffer{}
+	defer mockSlog(buf)()
+
+	v := viper.New()
+	v.Set("http.cors.allowed.origins", []string{"https://app.example.com"})
+	v.Set("http.cors.groups", "/public")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORS(v))
+	router.GET("/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	is := assert.New(t)
+	is.Contains(buf.String(), "http.cors.groups")
+	is.Equal("https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSWildcardCredentials(t *testing.T) {
+	buf := &bytes.Buffer{}
+	defer mockSlog(buf)()
+
+	v := viper.New()
+	v.Set("http.cors.allowed.origins", []string{"*"})
+	v.Set("http.cors.allow.credentials", true)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORS(v))
+	router.GET("/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Origin", "https://evil.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	is := assert.New(t)
+	is.Contains(buf.String(), "credentials are not allowed")
+	is.Equal("*", w.Header().Get("Access-Control-Allow-Origin"))
+	is.Empty(w.Header().Get("Access-Control-Allow-Credentials"))
+}
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cyg-pd/go-core/httprouter/render"
+	"github.com/cyg-pd/go-otelx"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var ErrOverloaded = errors.New(pkg + ": server overloaded")
+
+// Priority orders the requests to shed under load.
+type Priority int
+
+const (
+	// PriorityLow requests are shed once half of the limit is in flight.
+	PriorityLow Priority = iota - 1
+	// PriorityNormal requests are shed once the limit is in flight.
+	PriorityNormal
+	// PriorityCritical requests are never shed, but count as in flight.
+	PriorityCritical
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityCritical:
+		return "critical"
+	default:
+		return "normal"
+	}
+}
+
+// LoadShedPriorityByRoute prioritizes requests by route, as registered on the
+// router. Other routes are PriorityNormal.
+func LoadShedPriorityByRoute(routes map[string]Priority) func(c *gin.Context) Priority {
+	return func(c *gin.Context) Priority { return routes[c.FullPath()] }
+}
+
+// LoadShedPriorityByHeader prioritizes requests by the value of the header
+// name: "low", "normal" or "critical". Other values are PriorityNormal.
+func LoadShedPriorityByHeader(name string) func(c *gin.Context) Priority {
+	return func(c *gin.Context) Priority {
+		switch strings.ToLower(c.GetHeader(name)) {
+		case "low":
+			return PriorityLow
+		case "critical":
+			return PriorityCritical
+		default:
+			return PriorityNormal
+		}
+	}
+}
+
+// loadShedOption applies a configuration loadShedOption value to a LoadShed.
+type loadShedOption interface{ apply(*loadShed) }
+
+// loadShedOptionFunc applies a set of options to a config.
+type loadShedOptionFunc func(*loadShed)
+
+// apply returns a config with option(s) applied.
+func (o loadShedOptionFunc) apply(conf *loadShed) { o(conf) }
+
+// WithLoadShedPriority sets the priority of each request, PriorityNormal by
+// default.
+func WithLoadShedPriority(priority func(c *gin.Context) Priority) loadShedOption {
+	return loadShedOptionFunc(func(conf *loadShed) {
+		conf.priority = priority
+	})
+}
+
+// WithLoadShedRetryAfter sets the Retry-After of shed requests, 1s by
+// default.
+func WithLoadShedRetryAfter(d time.Duration) loadShedOption {
+	return loadShedOptionFunc(func(conf *loadShed) {
+		conf.retryAfter = d
+	})
+}
+
+type loadShed struct {
+	limit      LoadShedLimit
+	priority   func(c *gin.Context) Priority
+	retryAfter time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+
+	inFlightCounter metric.Int64UpDownCounter
+	shedCounter     metric.Int64Counter
+}
+
+// LoadShed caps the requests in flight at limit, answering the excess ones
+// at once with a 503 and Retry-After through render.RenderError, rather than
+// queueing them.
+func LoadShed(limit LoadShedLimit, opts ...loadShedOption) gin.HandlerFunc {
+	l := &loadShed{
+		limit:      limit,
+		priority:   func(*gin.Context) Priority { return PriorityNormal },
+		retryAfter: time.Second,
+	}
+	for _, opt := range opts {
+		opt.apply(l)
+	}
+
+	meter := otelx.Meter()
+	l.inFlightCounter, _ = meter.Int64UpDownCounter(
+		"http.server.load_shed.in_flight",
+		metric.WithDescription("The number of requests in flight"),
+	)
+	l.shedCounter, _ = meter.Int64Counter(
+		"http.server.load_shed.shed",
+		metric.WithDescription("The number of requests shed, by priority"),
+	)
+	limitGauge, _ := meter.Int64ObservableGauge(
+		"http.server.load_shed.limit",
+		metric.WithDescription("The current limit of requests in flight"),
+	)
+	_, _ = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		o.ObserveInt64(limitGauge, int64(l.limit.current()))
+		return nil
+	}, limitGauge)
+
+	return l.handle
+}
+
+func (l *loadShed) handle(c *gin.Context) {
+	ctx := c.Request.Context()
+	p := l.priority(c)
+
+	if !l.acquire(p) {
+		l.shedCounter.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("load_shed.priority", p.String()),
+			attribute.String("http.route", c.FullPath()),
+		))
+
+		c.Header("Retry-After", ceilSeconds(l.retryAfter))
+		render.RenderError(c, &render.StatusError{Code: http.StatusServiceUnavailable, Err: ErrOverloaded})
+		c.Abort()
+		return
+	}
+
+	l.inFlightCounter.Add(ctx, 1)
+	start := time.Now()
+
+	defer func() {
+		status := c.Writer.Status()
+		l.release(time.Since(start), status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout)
+		l.inFlightCounter.Add(context.WithoutCancel(ctx), -1)
+	}()
+
+	c.Next()
+}
+
+func (l *loadShed) acquire(p Priority) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := l.limit.current()
+	if p == PriorityLow {
+		limit /= 2
+	}
+	if p != PriorityCritical && l.inFlight >= limit {
+		return false
+	}
+
+	l.inFlight++
+	return true
+}
+
+func (l *loadShed) release(latency time.Duration, overloaded bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit.observe(latency, l.inFlight, overloaded)
+	l.inFlight--
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"math"
+	"time"
+)
+
+// LoadShedLimit is the number of requests LoadShed lets in flight, fixed or
+// adapted to the observed latencies, see FixedLimit, AIMDLimit and
+// GradientLimit. LoadShed serializes the calls.
+type LoadShedLimit interface {
+	// current returns the limit.
+	current() int
+	// observe adapts the limit to a completed request, which took latency
+	// with inFlight requests running, itself included; overloaded reports
+	// that it failed with a 503 or a 504.
+	observe(latency time.Duration, inFlight int, overloaded bool)
+}
+
+// FixedLimit lets limit requests in flight.
+func FixedLimit(limit int) LoadShedLimit { return fixedLimit(limit) }
+
+type fixedLimit int
+
+func (l fixedLimit) current() int                     { return int(l) }
+func (l fixedLimit) observe(time.Duration, int, bool) {}
+
+// AIMDLimit starts at initial requests in flight and, within [minLimit,
+// maxLimit], adds one request while they complete within threshold and the
+// limit is in use, and cuts it by 10% when one is slower or overloaded.
+func AIMDLimit(initial, minLimit, maxLimit int, threshold time.Duration) LoadShedLimit {
+	return &aimdLimit{limit: float64(initial), min: float64(minLimit), max: float64(maxLimit), threshold: threshold}
+}
+
+// aimdBackoff is the ratio applied to the AIMD limit on a slow request.
+const aimdBackoff = 0.9
+
+type aimdLimit struct {
+	limit, min, max float64
+	threshold       time.Duration
+}
+
+func (l *aimdLimit) current() int { return int(l.limit) }
+
+func (l *aimdLimit) observe(latency time.Duration, inFlight int, overloaded bool) {
+	switch {
+	case overloaded || latency > l.threshold:
+		l.limit = max(l.min, math.Floor(l.limit*aimdBackoff))
+	case float64(inFlight)*2 >= l.limit:
+		l.limit = min(l.max, l.limit+1)
+	}
+}
+
+// GradientLimit starts at initial requests in flight and, within [minLimit,
+// maxLimit], follows the ratio between the long-term average latency and the
+// latest one: the limit shrinks as requests queue up and latency grows, and
+// grows back by the square root of the limit once latency recovers.
+func GradientLimit(initial, minLimit, maxLimit int) LoadShedLimit {
+	return &gradientLimit{limit: float64(initial), min: float64(minLimit), max: float64(maxLimit)}
+}
+
+const (
+	// gradientWindow is the number of requests averaged by the long-term
+	// latency.
+	gradientWindow = 600
+	// gradientSmoothing weights each new limit against the current one.
+	gradientSmoothing = 0.2
+)
+
+type gradientLimit struct {
+	limit, min, max float64
+	longRTT         float64
+}
+
+func (l *gradientLimit) current() int { return int(l.limit) }
+
+func (l *gradientLimit) observe(latency time.Duration, inFlight int, overloaded bool) {
+	// Coarse clocks may report no latency, which would make the ratios NaN.
+	rtt := float64(max(latency, time.Nanosecond))
+	if l.longRTT == 0 {
+		l.longRTT = rtt
+	}
+	l.longRTT += (rtt - l.longRTT) / gradientWindow
+
+	// Let the long-term average catch up after a sustained drop in latency.
+	if l.longRTT/rtt > 2 {
+		l.longRTT *= 0.95
+	}
+
+	// Requests below half the limit tell nothing about the capacity.
+	if float64(inFlight) < l.limit/2 && !overloaded {
+		return
+	}
+
+	gradient := max(0.5, min(1, l.longRTT/rtt))
+	if overloaded {
+		gradient = 0.5
+	}
+
+	next := l.limit*gradient + math.Sqrt(l.limit)
+	l.limit = max(l.min, min(l.max, l.limit*(1-gradientSmoothing)+next*gradientSmoothing))
+}
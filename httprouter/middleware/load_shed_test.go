@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadShed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(LoadShed(FixedLimit(2), WithLoadShedPriority(LoadShedPriorityByHeader("X-Priority"))))
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	router.GET("/slow", func(c *gin.Context) {
+		started <- struct{}{}
+		<-release
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/fast", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	request := func(path, priority string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Priority", priority)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	is := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		request("/slow", "")
+	}()
+	<-started
+
+	// Half of the limit is in flight: low priority requests are shed.
+	w := request("/fast", "low")
+	is.Equal(http.StatusServiceUnavailable, w.Code)
+	is.Equal("1", w.Header().Get("Retry-After"))
+	is.Equal(http.StatusNoContent, request("/fast", "").Code)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		request("/slow", "")
+	}()
+	<-started
+
+	is.Equal(http.StatusServiceUnavailable, request("/fast", "").Code)
+	is.Equal(http.StatusNoContent, request("/fast", "critical").Code)
+
+	close(release)
+	wg.Wait()
+	is.Equal(http.StatusNoContent, request("/fast", "").Code)
+}
+
+func TestAIMDLimit(t *testing.T) {
+	l := AIMDLimit(10, 5, 11, 100*time.Millisecond)
+
+	is := assert.New(t)
+
+	l.observe(10*time.Millisecond, 2, false)
+	is.Equal(10, l.current(), "unused limit does not grow")
+
+	l.observe(10*time.Millisecond, 8, false)
+	l.observe(10*time.Millisecond, 8, false)
+	is.Equal(11, l.current())
+
+	l.observe(time.Second, 8, false)
+	is.Equal(9, l.current())
+
+	for range 10 {
+		l.observe(10*time.Millisecond, 8, true)
+	}
+	is.Equal(5, l.current())
+}
+
+func TestGradientLimit(t *testing.T) {
+	l := GradientLimit(20, 4, 100)
+	for range 100 {
+		l.observe(10*time.Millisecond, 20, false)
+	}
+
+	is := assert.New(t)
+	grown := l.current()
+	is.Greater(grown, 20)
+
+	for range 20 {
+		l.observe(100*time.Millisecond, grown, false)
+	}
+	is.Less(l.current(), grown)
+}
+
+func TestGradientLimitZeroLatency(t *testing.T) {
+	l := GradientLimit(20, 4, 100)
+	for range 10 {
+		l.observe(0, 20, false)
+	}
+
+	is := assert.New(t)
+	is.GreaterOrEqual(l.current(), 20)
+	is.LessOrEqual(l.current(), 100)
+
+	l.observe(10*time.Millisecond, 20, false)
+	is.GreaterOrEqual(l.current(), 4)
+	is.LessOrEqual(l.current(), 100)
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyg-pd/go-core/httprouter/render"
+	"github.com/cyg-pd/go-otelx"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var ErrRateLimited = errors.New(pkg + ": rate limit exceeded")
+
+// RateLimitKeyFunc returns the key a request is counted under. Requests with
+// an empty key are not limited.
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// RateLimitByIP counts requests per client IP, see gin.Context.ClientIP.
+func RateLimitByIP() RateLimitKeyFunc {
+	return func(c *gin.Context) string { return c.ClientIP() }
+}
+
+// RateLimitByHeader counts requests per value of the header name, such as an
+// API key. Requests without the header are not limited.
+func RateLimitByHeader(name string) RateLimitKeyFunc {
+	return func(c *gin.Context) string { return c.GetHeader(name) }
+}
+
+// RateLimitByRoute counts requests per route, as registered on the router,
+// whatever the client.
+func RateLimitByRoute() RateLimitKeyFunc {
+	return func(c *gin.Context) string { return c.FullPath() }
+}
+
+// rateLimitOption applies a configuration rateLimitOption value to a RateLimit.
+type rateLimitOption interface{ apply(*rateLimit) }
+
+// rateLimitOptionFunc applies a set of options to a config.
+type rateLimitOptionFunc func(*rateLimit)
+
+// apply returns a config with option(s) applied.
+func (o rateLimitOptionFunc) apply(conf *rateLimit) { o(conf) }
+
+// WithRateLimitKey sets how requests are grouped, by client IP by default.
+func WithRateLimitKey(key RateLimitKeyFunc) rateLimitOption {
+	return rateLimitOptionFunc(func(conf *rateLimit) {
+		conf.key = key
+	})
+}
+
+// WithRateLimitStore sets where the limits are kept, in memory by default.
+func WithRateLimitStore(store RateLimitStore) rateLimitOption {
+	return rateLimitOptionFunc(func(conf *rateLimit) {
+		conf.store = store
+	})
+}
+
+// WithRateLimitName names the limit in the store keys and the metrics, to
+// tell apart several limits sharing a store. Defaults to "default".
+func WithRateLimitName(name string) rateLimitOption {
+	return rateLimitOptionFunc(func(conf *rateLimit) {
+		conf.name = name
+	})
+}
+
+type rateLimit struct {
+	algorithm RateLimitAlgorithm
+	key       RateLimitKeyFunc
+	store     RateLimitStore
+	name      string
+
+	requests metric.Int64Counter
+}
+
+// RateLimit limits the requests following algorithm, answering 429 through
+// render.RenderError once exceeded. Responses carry the RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers, and Retry-After when
+// limited. Requests are allowed when the store fails.
+func RateLimit(algorithm RateLimitAlgorithm, opts ...rateLimitOption) gin.HandlerFunc {
+	r := &rateLimit{
+		algorithm: algorithm,
+		key:       RateLimitByIP(),
+		name:      "default",
+	}
+	for _, opt := range opts {
+		opt.apply(r)
+	}
+	if r.store == nil {
+		r.store = NewRateLimitMemoryStore()
+	}
+
+	r.requests, _ = otelx.Meter().Int64Counter(
+		"http.server.rate_limit.requests",
+		metric.WithDescription("The number of requests checked by the rate limit, by decision"),
+	)
+
+	return r.handle
+}
+
+func (r *rateLimit) handle(c *gin.Context) {
+	key := r.key(c)
+	if key == "" {
+		return
+	}
+
+	var res rateLimitResult
+	now := time.Now()
+	if err := r.store.Update(c.Request.Context(), "ratelimit:"+r.name+":"+key, r.algorithm.ttl(), func(state *RateLimitState) {
+		res = r.algorithm.take(state, now)
+	}); err != nil {
+		slog.Error(pkg+": rate limit store: "+err.Error(), slog.String("package", pkg))
+		return
+	}
+
+	decision := "allowed"
+	if !res.allowed {
+		decision = "limited"
+	}
+	r.requests.Add(c.Request.Context(), 1, metric.WithAttributes(
+		attribute.String("rate_limit.name", r.name),
+		attribute.String("rate_limit.decision", decision),
+		attribute.String("http.route", c.FullPath()),
+	))
+
+	c.Header("RateLimit-Limit", strconv.FormatInt(res.limit, 10))
+	c.Header("RateLimit-Remaining", strconv.FormatInt(res.remaining, 10))
+	c.Header("RateLimit-Reset", ceilSeconds(res.reset))
+
+	if !res.allowed {
+		c.Header("Retry-After", ceilSeconds(res.retryAfter))
+		render.RenderError(c, &render.StatusError{Code: http.StatusTooManyRequests, Err: ErrRateLimited})
+		c.Abort()
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
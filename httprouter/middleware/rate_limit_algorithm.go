@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"math"
+	"time"
+)
+
+// RateLimitAlgorithm decides whether a request is allowed, see TokenBucket
+// and SlidingWindow.
+type RateLimitAlgorithm interface {
+	// take counts a request against state at now.
+	take(state *RateLimitState, now time.Time) rateLimitResult
+	// ttl is how long a state is kept after its last request.
+	ttl() time.Duration
+}
+
+type rateLimitResult struct {
+	allowed    bool
+	limit      int64
+	remaining  int64
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+// TokenBucket allows bursts of up to burst requests, refilled at rate
+// requests per second.
+func TokenBucket(rate float64, burst int64) RateLimitAlgorithm {
+	return tokenBucket{rate: rate, burst: float64(burst)}
+}
+
+type tokenBucket struct{ rate, burst float64 }
+
+func (b tokenBucket) ttl() time.Duration { return seconds(b.burst / b.rate) }
+
+func (b tokenBucket) take(state *RateLimitState, now time.Time) rateLimitResult {
+	tokens := b.burst
+	if !state.Updated.IsZero() {
+		tokens = min(b.burst, state.Tokens+now.Sub(state.Updated).Seconds()*b.rate)
+	}
+
+	res := rateLimitResult{limit: int64(b.burst)}
+	if tokens >= 1 {
+		tokens--
+		res.allowed = true
+	} else {
+		res.retryAfter = seconds((1 - tokens) / b.rate)
+	}
+
+	state.Tokens, state.Updated = tokens, now
+	res.remaining = int64(tokens)
+	res.reset = seconds((b.burst - tokens) / b.rate)
+	return res
+}
+
+// SlidingWindow allows limit requests per window, weighting the count of the
+// previous window by how much of it the sliding window still covers.
+func SlidingWindow(limit int64, window time.Duration) RateLimitAlgorithm {
+	return slidingWindow{limit: limit, window: window}
+}
+
+type slidingWindow struct {
+	limit  int64
+	window time.Duration
+}
+
+func (w slidingWindow) ttl() time.Duration { return 2 * w.window }
+
+func (w slidingWindow) take(state *RateLimitState, now time.Time) rateLimitResult {
+	start := now.Truncate(w.window)
+	if !state.Window.Equal(start) {
+		if state.Window.Equal(start.Add(-w.window)) {
+			state.Previous = state.Current
+		} else {
+			state.Previous = 0
+		}
+		state.Window, state.Current = start, 0
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(w.window)
+	count := float64(state.Previous)*weight + float64(state.Current)
+
+	res := rateLimitResult{limit: w.limit, reset: w.window - elapsed}
+	if count+1 <= float64(w.limit) {
+		state.Current++
+		count++
+		res.allowed = true
+	} else {
+		res.retryAfter = w.retryAfter(state, elapsed)
+	}
+
+	res.remaining = max(0, w.limit-int64(math.Ceil(count)))
+	return res
+}
+
+// retryAfter returns when the previous window weighs little enough for a
+// request to be allowed, or the start of the next window.
+func (w slidingWindow) retryAfter(state *RateLimitState, elapsed time.Duration) time.Duration {
+	room := float64(w.limit - 1 - state.Current)
+	if state.Previous == 0 || room < 0 {
+		return w.window - elapsed
+	}
+
+	due := time.Duration((1 - room/float64(state.Previous)) * float64(w.window))
+	return max(0, due-elapsed)
+}
+
+func seconds(s float64) time.Duration { return time.Duration(s * float64(time.Second)) }
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimitState is the state of a rate limit key, as kept by a
+// RateLimitStore. Each algorithm uses its own fields.
+type RateLimitState struct {
+	// Token bucket: tokens left at Updated.
+	Tokens  float64   `json:"tokens,omitempty"`
+	Updated time.Time `json:"updated,omitzero"`
+
+	// Sliding window: requests counted in the window starting at Window and
+	// in the previous one.
+	Window   time.Time `json:"window,omitzero"`
+	Current  int64     `json:"current,omitempty"`
+	Previous int64     `json:"previous,omitempty"`
+}
+
+// RateLimitStore keeps the state of the rate limit keys. Stores backed by
+// Redis or the like share the limits between instances; Update must then
+// be atomic, for instance with WATCH and MULTI or a Lua script.
+type RateLimitStore interface {
+	// Update passes the state of key, zero if unknown or expired, to fn and
+	// saves it for ttl.
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(state *RateLimitState)) error
+}
+
+// RateLimitMemoryStore keeps the rate limits in memory, per instance. It is
+// the default store of RateLimit.
+type RateLimitMemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*rateLimitEntry
+	nextSweep time.Time
+}
+
+type rateLimitEntry struct {
+	state   RateLimitState
+	expires time.Time
+}
+
+// rateLimitSweepInterval is how often expired keys are dropped.
+const rateLimitSweepInterval = time.Minute
+
+func NewRateLimitMemoryStore() *RateLimitMemoryStore {
+	return &RateLimitMemoryStore{entries: map[string]*rateLimitEntry{}}
+}
+
+func (s *RateLimitMemoryStore) Update(_ context.Context, key string, ttl time.Duration, fn func(state *RateLimitState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.After(s.nextSweep) {
+		for k, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.nextSweep = now.Add(rateLimitSweepInterval)
+	}
+
+	e, ok := s.entries[key]
+	if !ok || now.After(e.expires) {
+		e = &rateLimitEntry{}
+		s.entries[key] = e
+	}
+
+	fn(&e.state)
+	e.expires = now.Add(ttl)
+	return nil
+}
+
+var _ RateLimitStore = (*RateLimitMemoryStore)(nil)
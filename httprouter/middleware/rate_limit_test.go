@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRateLimitRouter(algorithm RateLimitAlgorithm, opts ...rateLimitOption) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RateLimit(algorithm, opts...))
+	router.GET("/limited", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return router
+}
+
+func rateLimitRequest(router *gin.Engine, header string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+	if header != "" {
+		req.Header.Set("X-Api-Key", header)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitTokenBucket(t *testing.T) {
+	router := createRateLimitRouter(TokenBucket(1, 2))
+
+	is := assert.New(t)
+	for _, remaining := range []string{"1", "0"} {
+		w := rateLimitRequest(router, "")
+		is.Equal(http.StatusNoContent, w.Code)
+		is.Equal("2", w.Header().Get("RateLimit-Limit"))
+		is.Equal(remaining, w.Header().Get("RateLimit-Remaining"))
+	}
+
+	w := rateLimitRequest(router, "")
+	is.Equal(http.StatusTooManyRequests, w.Code)
+	is.Equal("1", w.Header().Get("Retry-After"))
+}
+
+func TestRateLimitSlidingWindowByHeader(t *testing.T) {
+	router := createRateLimitRouter(SlidingWindow(1, time.Hour), WithRateLimitKey(RateLimitByHeader("X-Api-Key")))
+
+	is := assert.New(t)
+	is.Equal(http.StatusNoContent, rateLimitRequest(router, "a").Code)
+	is.Equal(http.StatusNoContent, rateLimitRequest(router, "b").Code)
+
+	w := rateLimitRequest(router, "a")
+	is.Equal(http.StatusTooManyRequests, w.Code)
+	is.NotEmpty(w.Header().Get("Retry-After"))
+
+	// Requests without key are not limited.
+	is.Equal(http.StatusNoContent, rateLimitRequest(router, "").Code)
+	is.Equal(http.StatusNoContent, rateLimitRequest(router, "").Code)
+}
+
+func TestSlidingWindowWeight(t *testing.T) {
+	w := SlidingWindow(10, time.Minute)
+	start := time.Now().Truncate(time.Minute)
+	state := &RateLimitState{Window: start.Add(-time.Minute), Current: 10}
+
+	is := assert.New(t)
+
+	// Halfway through the window, half of the previous one still counts.
+	res := w.take(state, start.Add(30*time.Second))
+	is.True(res.allowed)
+	is.EqualValues(4, res.remaining)
+
+	state.Current = 5
+	res = w.take(state, start.Add(30*time.Second))
+	is.False(res.allowed)
+	is.Equal(6*time.Second, res.retryAfter)
+}
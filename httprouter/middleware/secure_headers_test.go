@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func secureHeadersRequest(v *viper.Viper, https bool) (*httptest.ResponseRecorder, string) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(SecureHeaders(v))
+
+	var nonce string
+	router.GET("/", func(c *gin.Context) {
+		nonce = CSPNonce(c)
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if https {
+		req.TLS = &tls.ConnectionState{}
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w, nonce
+}
+
+func TestSecureHeaders(t *testing.T) {
+	w, nonce := secureHeadersRequest(nil, true)
+
+	is := assert.New(t)
+	is.Equal("nosniff", w.Header().Get("X-Content-Type-Options"))
+	is.Equal("DENY", w.Header().Get("X-Frame-Options"))
+	is.NotEmpty(w.Header().Get("Strict-Transport-Security"))
+	is.NotEmpty(w.Header().Get("Referrer-Policy"))
+	is.NotEmpty(w.Header().Get("Permissions-Policy"))
+	is.NotEmpty(nonce)
+	is.Contains(w.Header().Get("Content-Security-Policy"), "script-src 'self' 'nonce-"+nonce+"'")
+
+	_, other := secureHeadersRequest(nil, true)
+	is.NotEqual(nonce, other)
+}
+
+func TestSecureHeadersConfig(t *testing.T) {
+	v := viper.New()
+	v.Set("http.secure.headers.frame.options", "SAMEORIGIN")
+	v.Set("http.secure.headers.permissions.policy", "")
+	v.Set("http.secure.headers.csp.policy", "default-src 'self'")
+	v.Set("http.secure.headers.csp.report.only", true)
+
+	w, nonce := secureHeadersRequest(v, false)
+
+	is := assert.New(t)
+	is.Equal("SAMEORIGIN", w.Header().Get("X-Frame-Options"))
+	is.Empty(w.Header().Get("Strict-Transport-Security"))
+	is.Empty(w.Header().Get("Permissions-Policy"))
+	is.Empty(w.Header().Get("Content-Security-Policy"))
+	is.Equal("default-src 'self'", w.Header().Get("Content-Security-Policy-Report-Only"))
+	is.Empty(nonce)
+}
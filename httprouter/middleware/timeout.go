@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/cyg-pd/go-core/httprouter/render"
+	"github.com/gin-gonic/gin"
+)
+
+var ErrTimeout = errors.New(pkg + ": request timed out")
+
+// timeoutOption applies a configuration timeoutOption value to a Timeout.
+type timeoutOption interface{ apply(*timeout) }
+
+// timeoutOptionFunc applies a set of options to a config.
+type timeoutOptionFunc func(*timeout)
+
+// apply returns a config with option(s) applied.
+func (o timeoutOptionFunc) apply(conf *timeout) { o(conf) }
+
+// WithTimeoutRoute overrides the timeout for the route, as registered on the
+// router such as "/reports/:id". A timeout of 0 removes it.
+func WithTimeoutRoute(route string, d time.Duration) timeoutOption {
+	return timeoutOptionFunc(func(conf *timeout) {
+		conf.routes[route] = d
+	})
+}
+
+// WithTimeoutStatus sets the status answered on timeout, 503 by default;
+// 504 suits handlers timing out on upstream services.
+func WithTimeoutStatus(code int) timeoutOption {
+	return timeoutOptionFunc(func(conf *timeout) {
+		conf.status = code
+	})
+}
+
+type timeout struct {
+	timeout time.Duration
+	routes  map[string]time.Duration
+	status  int
+}
+
+// Timeout sets a deadline of d on the request context, inherited by the
+// calls made with it such as httpclient requests. The response is buffered:
+// when the handler returns past the deadline, what it wrote is discarded and
+// the timeout is answered through render.RenderError instead. Handlers
+// flushing their response, such as streams, are not buffered past the first
+// flush. A timeout of 0 removes it.
+//
+// The timeout only acts through the context: the handler runs on the request
+// goroutine, as gin.Context cannot be shared, and the timeout is answered
+// once it returns. A handler blocked on I/O that ignores its context keeps
+// the client waiting past the deadline; bound such calls with their own
+// deadlines.
+func Timeout(d time.Duration, opts ...timeoutOption) gin.HandlerFunc {
+	t := &timeout{timeout: d, routes: map[string]time.Duration{}, status: http.StatusServiceUnavailable}
+	for _, opt := range opts {
+		opt.apply(t)
+	}
+
+	return t.handle
+}
+
+func (t *timeout) handle(c *gin.Context) {
+	d := t.timeout
+	if r, ok := t.routes[c.FullPath()]; ok {
+		d = r
+	}
+	if d <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+	defer cancel()
+
+	req := c.Request
+	c.Request = req.WithContext(ctx)
+	defer func() { c.Request = req }()
+
+	w := newTimeoutWriter(c.Writer)
+	c.Writer = w
+	defer func() { c.Writer = w.ResponseWriter }()
+
+	c.Next()
+
+	if w.passthrough {
+		return
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		c.Writer = w.ResponseWriter
+		render.RenderError(c, &render.StatusError{Code: t.status, Err: ErrTimeout})
+		return
+	}
+
+	w.commit()
+}
+
+// timeoutWriter buffers the response until the handler returns, or until it
+// flushes, after which it writes through.
+type timeoutWriter struct {
+	gin.ResponseWriter
+
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	size        int
+	passthrough bool
+}
+
+func newTimeoutWriter(w gin.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{
+		ResponseWriter: w,
+		header:         w.Header().Clone(),
+		status:         w.Status(),
+		size:           -1,
+	}
+}
+
+// commit writes the buffered response and switches to writing through.
+func (w *timeoutWriter) commit() {
+	if w.passthrough {
+		return
+	}
+	w.passthrough = true
+
+	h := w.ResponseWriter.Header()
+	clear(h)
+	for k, v := range w.header {
+		h[k] = v
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.size >= 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	if w.passthrough {
+		return w.ResponseWriter.Header()
+	}
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 && !w.Written() {
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	if !w.Written() {
+		w.size = 0
+	}
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	w.WriteHeaderNow()
+	n, err := w.buf.Write(b)
+	w.size += n
+	return n, err
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Status() int {
+	if w.passthrough {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	if w.passthrough {
+		return w.ResponseWriter.Size()
+	}
+	return w.size
+}
+
+func (w *timeoutWriter) Written() bool {
+	if w.passthrough {
+		return w.ResponseWriter.Written()
+	}
+	return w.size != -1
+}
+
+func (w *timeoutWriter) Flush() {
+	w.commit()
+	w.ResponseWriter.Flush()
+}
+
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.commit()
+	return w.ResponseWriter.Hijack()
+}
+
+func (w *timeoutWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+var _ gin.ResponseWriter = (*timeoutWriter)(nil)
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Hostname(), Timeout(50*time.Millisecond,
+		WithTimeoutRoute("/report", time.Second),
+		WithTimeoutStatus(http.StatusGatewayTimeout),
+	))
+
+	wait := func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(100 * time.Millisecond):
+		}
+		c.Header("X-Late", "1")
+		c.String(http.StatusOK, "late")
+	}
+	router.GET("/slow", wait)
+	router.GET("/report", wait)
+	router.GET("/fast", func(c *gin.Context) {
+		if _, ok := c.Request.Context().Deadline(); ok {
+			c.Header("X-Deadline", "yes")
+		}
+		c.String(http.StatusCreated, "fast")
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/fast", http.StatusCreated, "fast"},
+		{"/slow", http.StatusGatewayTimeout, ""},
+		{"/report", http.StatusOK, "late"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			is := assert.New(t)
+			is.Equal(tt.code, w.Code)
+			is.NotEmpty(w.Header().Get("X-Host-Name"))
+			if tt.body != "" {
+				is.Equal(tt.body, w.Body.String())
+			} else {
+				is.NotContains(w.Body.String(), "late")
+				is.Empty(w.Header().Get("X-Late"))
+			}
+			if tt.path == "/fast" {
+				is.Equal("yes", w.Header().Get("X-Deadline"))
+			}
+		})
+	}
+}
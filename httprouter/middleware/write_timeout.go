@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WriteTimeout overrides the write timeout of the server for the routes it
+// is used on, such as streaming endpoints or file uploads, whose response
+// takes longer than the one of a JSON API. Zero means no timeout.
+func WriteTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var deadline time.Time
+		if timeout > 0 {
+			deadline = time.Now().Add(timeout)
+		}
+
+		// Writers not supporting deadlines, such as in tests, keep the
+		// timeout of the server.
+		_ = http.NewResponseController(c.Writer).SetWriteDeadline(deadline)
+	}
+}
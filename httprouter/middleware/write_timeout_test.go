@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	slow := func(c *gin.Context) {
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	}
+	router.GET("/default", slow)
+	router.GET("/stream", WriteTimeout(5*time.Second), slow)
+
+	srv := httptest.NewUnstartedServer(router)
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	is := assert.New(t)
+
+	_, err := http.Get(srv.URL + "/default")
+	is.Error(err)
+
+	res, err := http.Get(srv.URL + "/stream")
+	if is.NoError(err) {
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		is.Equal("done", string(body))
+	}
+}
@@ -1,6 +1,8 @@
 package httprouter
 
 import (
+	"net"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,3 +16,19 @@ func WithEngine(engine *gin.Engine) option {
 		r.Engine = engine
 	})
 }
+
+// WithListener serves ln instead of listening on the configured address, for
+// instance a listener inherited from a parent process or created by a test.
+func WithListener(ln net.Listener) option {
+	return optionFunc(func(r *server) {
+		r.preBound = ln
+	})
+}
+
+// WithListeners serves the engine on the additional addresses ls, next to
+// the configured one.
+func WithListeners(ls ...Listener) option {
+	return optionFunc(func(r *server) {
+		r.extraListeners = append(r.extraListeners, ls...)
+	})
+}
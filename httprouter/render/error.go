@@ -1,6 +1,7 @@
 package render
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -12,10 +13,25 @@ var fallbackErrorRender ErrorRenderFunc = func(err error) (Renderable, bool) {
 
 func SetFallbackErrorRender(h ErrorRenderFunc) { fallbackErrorRender = h }
 
+// StatusError is an error rendered with the HTTP status Code by the default
+// error render. Renders registered with RegisterErrorRender take precedence,
+// so applications can change how it is rendered.
+type StatusError struct {
+	Code int
+	Err  error
+}
+
+func (e *StatusError) Error() string { return e.Err.Error() }
+func (e *StatusError) Unwrap() error { return e.Err }
+
 type defaultErrorRender struct{ err error }
 
 func (d *defaultErrorRender) Render(ctx *gin.Context) {
-	Negotiate(ctx, http.StatusInternalServerError, d.err.Error())
+	code := http.StatusInternalServerError
+	if se := (*StatusError)(nil); errors.As(d.err, &se) {
+		code = se.Code
+	}
+	Negotiate(ctx, code, d.err.Error())
 }
 
 var _ Renderable = (*defaultErrorRender)(nil)
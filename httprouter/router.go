@@ -2,6 +2,7 @@ package httprouter
 
 import (
 	"context"
+	"net"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
@@ -19,10 +20,67 @@ type Engine interface {
 
 type Server struct{ Engine }
 
+// StopAccepting stops accepting new connections and requests, leaving the
+// in-flight ones to be drained by Shutdown. Engines that cannot stop
+// accepting separately do nothing.
+func (s *Server) StopAccepting() error {
+	if e, ok := s.Engine.(interface{ StopAccepting() error }); ok {
+		return e.StopAccepting()
+	}
+	return nil
+}
+
+// Addr returns the address the server listens on, the first one with
+// WithListeners, with port 0 resolved to the port actually bound, or nil
+// until it listens or when the engine does not report it.
+func (s *Server) Addr() net.Addr {
+	if e, ok := s.Engine.(interface{ Addr() net.Addr }); ok {
+		return e.Addr()
+	}
+	return nil
+}
+
+// Addrs returns the addresses of every listener, in order, see
+// WithListeners.
+func (s *Server) Addrs() []net.Addr {
+	if e, ok := s.Engine.(interface{ Addrs() []net.Addr }); ok {
+		return e.Addrs()
+	}
+	if addr := s.Addr(); addr != nil {
+		return []net.Addr{addr}
+	}
+	return nil
+}
+
+// Listening returns a channel closed once the server listens. Engines that
+// do not report it are considered listening right away.
+func (s *Server) Listening() <-chan struct{} {
+	if e, ok := s.Engine.(interface{ Listening() <-chan struct{} }); ok {
+		return e.Listening()
+	}
+	return closed
+}
+
+// Routes returns the routes registered on the engine, or nil when the engine
+// does not report them.
+func (s *Server) Routes() gin.RoutesInfo {
+	if e, ok := s.Engine.(interface{ Routes() gin.RoutesInfo }); ok {
+		return e.Routes()
+	}
+	return nil
+}
+
+var closed = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
 func New(config *viper.Viper, opts ...option) *Server {
 	r := &server{
-		Engine: gin.New(),
-		config: config,
+		Engine:    gin.New(),
+		config:    config,
+		listening: make(chan struct{}),
 	}
 	for _, opt := range opts {
 		opt.apply(r)
@@ -2,25 +2,52 @@ package httprouter
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/quic-go/quic-go/http3"
 	"github.com/spf13/viper"
+	"golang.org/x/net/netutil"
+	"golang.org/x/sync/errgroup"
 )
 
+// ErrServerNotRunning is returned when stopping a server that is not running,
+// for instance because it failed to listen.
+var ErrServerNotRunning = errors.New("core/httprouter: server is not running")
+
 type server struct {
 	*gin.Engine
-	server *http.Server
 	config *viper.Viper
+
+	// preBound is served instead of listening on the configured address, see
+	// WithListener.
+	preBound       net.Listener
+	extraListeners []Listener
+
+	mu      sync.Mutex
+	servers []*boundServer
+	http3   *http3.Server
+	stopped bool
+
+	listening     chan struct{}
+	listeningOnce sync.Once
+}
+
+// boundServer serves the engine on one listener.
+type boundServer struct {
+	*http.Server
+	listener net.Listener
 }
 
-func (e *server) createHTTPServer(host string, h http.Handler) *http.Server {
+func (e *server) createHTTPServer(host string, h http.Handler, l Listener) *http.Server {
 	s := &http.Server{
 		Addr:                         host,
 		Handler:                      h,
@@ -31,6 +58,14 @@ func (e *server) createHTTPServer(host string, h http.Handler) *http.Server {
 		IdleTimeout:                  120 * time.Second,
 	}
 
+	if e.config.GetBool("http.h2c") {
+		slog.Debug("core/httprouter: http.h2c is enabled")
+		s.Protocols = new(http.Protocols)
+		s.Protocols.SetHTTP1(true)
+		s.Protocols.SetHTTP2(true)
+		s.Protocols.SetUnencryptedHTTP2(true)
+	}
+
 	if t := e.config.GetDuration("http.server.idle.timeout"); t > 0 {
 		slog.Debug("core/httprouter: http.server.idle.timeout is set to " + t.String())
 		s.IdleTimeout = t
@@ -47,36 +82,227 @@ func (e *server) createHTTPServer(host string, h http.Handler) *http.Server {
 		s.ReadTimeout = t
 	}
 
+	if t := e.config.GetDuration("http.server.read.header.timeout"); t > 0 {
+		slog.Debug("core/httprouter: http.server.read.header.timeout is set to " + t.String())
+		s.ReadHeaderTimeout = t
+	}
+
+	if n := e.config.GetInt("http.server.max.header.bytes"); n > 0 {
+		slog.Debug("core/httprouter: http.server.max.header.bytes is set to " + strconv.Itoa(n))
+		s.MaxHeaderBytes = n
+	}
+
+	if e.config.GetBool("http.server.disable.keepalive") {
+		slog.Debug("core/httprouter: http.server.disable.keepalive is set")
+		s.SetKeepAlivesEnabled(false)
+	}
+
+	// Timeouts of the listener override the ones of the server.
+	for _, t := range []struct {
+		value time.Duration
+		field *time.Duration
+	}{
+		{l.ReadTimeout, &s.ReadTimeout},
+		{l.ReadHeaderTimeout, &s.ReadHeaderTimeout},
+		{l.WriteTimeout, &s.WriteTimeout},
+		{l.IdleTimeout, &s.IdleTimeout},
+	} {
+		if t.value > 0 {
+			*t.field = t.value
+		}
+	}
+
 	return s
 }
 
+// maxConnections returns the limit of concurrent connections accepted by l,
+// 0 meaning no limit.
+func (e *server) maxConnections(l Listener) int {
+	if l.MaxConnections > 0 {
+		return l.MaxConnections
+	}
+	return e.config.GetInt("http.server.max.connections")
+}
+
+// Run serves the engine on every host, or on the configured address, and on
+// the additional listeners, until Shutdown is called or one of them fails.
 func (e *server) Run(host ...string) error {
-	if len(host) == 0 {
-		host = append(
-			host,
-			e.config.GetString("http.host")+":"+strconv.Itoa(e.config.GetInt("http.port")),
-		)
+	ls, err := e.listeners(host...)
+	if err != nil {
+		return err
 	}
 
-	ln, err := net.Listen("tcp", host[0])
+	tlsConfig, err := e.tlsConfig()
 	if err != nil {
-		return fmt.Errorf("core/httprouter: %w", err)
+		return err
+	}
+
+	lns := make([]net.Listener, 0, len(ls))
+	closeAll := func() {
+		for _, ln := range lns {
+			_ = ln.Close()
+		}
+	}
+
+	for i, l := range ls {
+		var ln net.Listener
+		if i == 0 && len(host) == 0 && e.preBound != nil {
+			ln = e.preBound
+		} else if ln, err = listen(l); err != nil {
+			closeAll()
+			return err
+		}
+
+		if n := e.maxConnections(l); n > 0 {
+			ln = netutil.LimitListener(ln, n)
+		}
+		if tlsConfig != nil {
+			ln = tls.NewListener(ln, tlsConfig)
+		}
+		lns = append(lns, ln)
+	}
+
+	// HTTP/3 is served on the UDP port of the first listener.
+	handler := http.AllowQuerySemicolons(e.Handler())
+	h3, udp, err := e.listenHTTP3(lns[0].Addr(), tlsConfig, handler)
+	if err != nil {
+		closeAll()
+		return err
+	}
+	if h3 != nil {
+		handler = altSvc(h3, handler)
+	}
+
+	servers := make([]*boundServer, len(lns))
+	for i, ln := range lns {
+		servers[i] = &boundServer{
+			Server:   e.createHTTPServer(ln.Addr().String(), handler, ls[i]),
+			listener: &onceCloseListener{Listener: ln},
+		}
+	}
+
+	e.mu.Lock()
+	e.servers, e.http3, e.stopped = servers, h3, false
+	e.mu.Unlock()
+	e.listeningOnce.Do(func() { close(e.listening) })
+
+	// A server failing stops the others, so Run reports the failure.
+	var failOnce sync.Once
+	fail := func() {
+		failOnce.Do(func() {
+			for _, s := range servers {
+				_ = s.Close()
+			}
+			if h3 != nil {
+				_ = h3.Close()
+			}
+		})
+	}
+
+	var g errgroup.Group
+	if h3 != nil {
+		g.Go(func() error {
+			defer udp.Close()
+
+			slog.Info("core/httprouter: serving HTTP/3 on " + udp.LocalAddr().String())
+			if err := h3.Serve(udp); err != nil && err != http.ErrServerClosed && !e.isStopped() {
+				fail()
+				return fmt.Errorf("core/httprouter: http3: %w", err)
+			}
+			return nil
+		})
+	}
+
+	scheme := "HTTP"
+	if tlsConfig != nil {
+		scheme = "HTTPS"
+	}
+
+	for _, s := range servers {
+		g.Go(func() error {
+			slog.Info("core/httprouter: serving " + scheme + " on " + s.listener.Addr().String())
+			if err := s.Serve(s.listener); err != nil && err != http.ErrServerClosed {
+				if e.isStopped() && errors.Is(err, net.ErrClosed) {
+					return nil
+				}
+				fail()
+				return fmt.Errorf("core/httprouter: %w", err)
+			}
+			return nil
+		})
 	}
 
-	e.server = e.createHTTPServer(ln.Addr().String(), http.AllowQuerySemicolons(e.Handler()))
+	return g.Wait()
+}
+
+// Addr returns the address of the first listener, resolving port 0, or nil
+// until the server listens.
+func (e *server) Addr() net.Addr {
+	if addrs := e.Addrs(); len(addrs) > 0 {
+		return addrs[0]
+	}
+	return nil
+}
+
+// Addrs returns the addresses of every listener, in order.
+func (e *server) Addrs() []net.Addr {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	addrs := make([]net.Addr, len(e.servers))
+	for i, s := range e.servers {
+		addrs[i] = s.listener.Addr()
+	}
+	return addrs
+}
+
+// Listening returns a channel closed once the server listens.
+func (e *server) Listening() <-chan struct{} { return e.listening }
+
+func (e *server) isStopped() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.stopped
+}
+
+// StopAccepting closes the listeners and disables keep-alives, so no new
+// connection or request is accepted while in-flight requests complete.
+func (e *server) StopAccepting() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
-	slog.Info("core/httprouter: serving HTTP on " + ln.Addr().String())
-	if err := e.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+	if e.servers == nil {
+		return ErrServerNotRunning
+	}
+
+	e.stopped = true
+	errs := []error{}
+	for _, s := range e.servers {
+		s.SetKeepAlivesEnabled(false)
+		errs = append(errs, s.listener.Close())
+	}
+
+	if err := errors.Join(errs...); err != nil {
 		return fmt.Errorf("core/httprouter: %w", err)
 	}
 
 	return nil
 }
 
+// Shutdown gracefully shuts down every listener together.
 func (e *server) Shutdown(ctx context.Context) error {
 	c := e.config
-	if e.server == nil {
-		return errors.New("core/httprouter: server is not running")
+
+	e.mu.Lock()
+	servers, h3 := e.servers, e.http3
+	if servers != nil {
+		e.stopped = true
+	}
+	e.mu.Unlock()
+
+	if servers == nil {
+		return ErrServerNotRunning
 	}
 
 	var cancel context.CancelFunc
@@ -85,11 +311,50 @@ func (e *server) Shutdown(ctx context.Context) error {
 		defer cancel()
 	}
 
-	if err := e.server.Shutdown(ctx); err != nil {
+	var mu sync.Mutex
+	errs := []error{}
+	collect := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}
+
+	shutdowns := make([]func(context.Context) error, 0, len(servers)+1)
+	for _, s := range servers {
+		shutdowns = append(shutdowns, s.Shutdown)
+	}
+	if h3 != nil {
+		shutdowns = append(shutdowns, h3.Shutdown)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(shutdowns))
+	for _, shutdown := range shutdowns {
+		go func() {
+			defer wg.Done()
+			collect(shutdown(ctx))
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
 		return fmt.Errorf("core/httprouter: %w", err)
 	}
 
 	return nil
 }
 
+// onceCloseListener lets StopAccepting close the listener ahead of
+// http.Server.Shutdown, which closes it again.
+type onceCloseListener struct {
+	net.Listener
+	once sync.Once
+	err  error
+}
+
+func (l *onceCloseListener) Close() error {
+	l.once.Do(func() { l.err = l.Listener.Close() })
+	return l.err
+}
+
 var _ Engine = (*server)(nil)
@@ -0,0 +1,65 @@
+package httprouter
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestH2C(t *testing.T) {
+	v := viper.New()
+	v.Set("http.host", "127.0.0.1")
+	v.Set("http.h2c", true)
+
+	s := New(v)
+	s.GET("/proto", func(c *gin.Context) { c.String(http.StatusOK, c.Request.Proto) })
+	serve(t, s)
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	body, err := get(client, "http://"+s.Addr().String()+"/proto")
+
+	is := assert.New(t)
+	is.NoError(err)
+	is.Equal("HTTP/2.0", body)
+}
+
+func TestServerTuning(t *testing.T) {
+	v := viper.New()
+	v.Set("http.host", "127.0.0.1")
+	v.Set("http.server.read.header.timeout", "2s")
+	v.Set("http.server.max.header.bytes", 4096)
+	v.Set("http.server.max.connections", 1)
+
+	s := New(v)
+	serve(t, s)
+
+	is := assert.New(t)
+	srv := s.Engine.(*server).servers[0]
+	is.Equal(2*time.Second, srv.ReadHeaderTimeout)
+	is.Equal(4096, srv.MaxHeaderBytes)
+
+	// The only connection allowed is held open, so the next one waits.
+	conn, err := net.Dial("tcp", s.Addr().String())
+	if !is.NoError(err) {
+		return
+	}
+
+	url := "http://" + s.Addr().String() + "/ping"
+	client := &http.Client{Timeout: 300 * time.Millisecond, Transport: &http.Transport{DisableKeepAlives: true}}
+	_, err = get(client, url)
+	is.Error(err)
+
+	_ = conn.Close()
+	client.Timeout = 5 * time.Second
+	body, err := get(client, url)
+	is.NoError(err)
+	is.Equal("pong", body)
+}
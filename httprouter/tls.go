@@ -0,0 +1,156 @@
+package httprouter
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client certificate verification modes accepted by the http.tls.client.auth
+// key. Without a mode, clients must present a certificate signed by
+// http.tls.client.ca when it is set.
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify-if-given":    tls.VerifyClientCertIfGiven,
+	"require-and-verify": tls.RequireAndVerifyClientCert,
+}
+
+var ErrInvalidClientAuth = errors.New("core/httprouter: invalid TLS client auth")
+
+// tlsConfig returns the TLS configuration read from the http.tls.* keys, or
+// nil when no certificate is configured.
+func (e *server) tlsConfig() (*tls.Config, error) {
+	c := e.config
+	certFile, keyFile := c.GetString("http.tls.cert"), c.GetString("http.tls.key")
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	r := &certReloader{
+		certFile:   certFile,
+		keyFile:    keyFile,
+		caFile:     c.GetString("http.tls.client.ca"),
+		clientAuth: tls.NoClientCert,
+		interval:   time.Second,
+	}
+
+	if r.caFile != "" {
+		r.clientAuth = tls.RequireAndVerifyClientCert
+	}
+	if mode := c.GetString("http.tls.client.auth"); mode != "" {
+		auth, ok := clientAuthTypes[strings.ToLower(mode)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidClientAuth, mode)
+		}
+		r.clientAuth = auth
+	}
+
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{GetConfigForClient: r.GetConfigForClient}, nil
+}
+
+// certReloader serves the certificate and client CA read from disk, reloading
+// them when the files change, so renewed certificates need no restart. The
+// files are checked at most once per interval, on handshake.
+type certReloader struct {
+	certFile, keyFile, caFile string
+	clientAuth                tls.ClientAuthType
+	interval                  time.Duration
+
+	mu      sync.Mutex
+	checked time.Time
+	stamp   string
+	config  *tls.Config
+}
+
+func (r *certReloader) GetConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) >= r.interval {
+		r.checked = time.Now()
+		if stamp, err := r.fileStamp(); err == nil && stamp != r.stamp {
+			// A failed reload keeps serving the previous certificate, e.g.
+			// while the key is being written after the certificate.
+			if err := r.load(stamp); err != nil {
+				slog.Error("core/httprouter: reload TLS certificate: " + err.Error())
+			} else {
+				slog.Info("core/httprouter: reloaded TLS certificate " + r.certFile)
+			}
+		}
+	}
+
+	return r.config, nil
+}
+
+func (r *certReloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stamp, err := r.fileStamp()
+	if err != nil {
+		return err
+	}
+
+	r.checked = time.Now()
+	return r.load(stamp)
+}
+
+// load reads the files, with r.mu held.
+func (r *certReloader) load(stamp string) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("core/httprouter: %w", err)
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   r.clientAuth,
+	}
+
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("core/httprouter: %w", err)
+		}
+
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("core/httprouter: no certificate found in %s", r.caFile)
+		}
+	}
+
+	r.config, r.stamp = config, stamp
+	return nil
+}
+
+// fileStamp identifies the version of the files by their size and
+// modification time, following symlinks as swapped by Kubernetes.
+func (r *certReloader) fileStamp() (string, error) {
+	var b strings.Builder
+	for _, name := range []string{r.certFile, r.keyFile, r.caFile} {
+		if name == "" {
+			continue
+		}
+
+		fi, err := os.Stat(name)
+		if err != nil {
+			return "", fmt.Errorf("core/httprouter: %w", err)
+		}
+		fmt.Fprintf(&b, "%d:%d;", fi.Size(), fi.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,171 @@
+package httprouter
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pair tls.Certificate
+}
+
+func newTestCert(t *testing.T, serial int64, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	return &testCert{cert: cert, key: key, pair: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}}
+}
+
+func (c *testCert) write(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if keyFile != "" {
+		if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func servedSerial(t *testing.T, addr string, config *tls.Config) (int64, error) {
+	t.Helper()
+
+	conn, err := tls.Dial("tcp", addr, config)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func TestTLSReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	newTestCert(t, 1, nil).write(t, certFile, keyFile)
+
+	v := viper.New()
+	v.Set("http.host", "127.0.0.1")
+	v.Set("http.tls.cert", certFile)
+	v.Set("http.tls.key", keyFile)
+
+	s := New(v)
+	serve(t, s)
+
+	is := assert.New(t)
+	client := &tls.Config{InsecureSkipVerify: true}
+
+	serial, err := servedSerial(t, s.Addr().String(), client)
+	is.NoError(err)
+	is.EqualValues(1, serial)
+
+	renewed := newTestCert(t, 2, nil)
+	renewed.write(t, certFile, keyFile)
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(certFile, future, future)
+
+	is.Eventually(func() bool {
+		serial, err := servedSerial(t, s.Addr().String(), client)
+		return err == nil && serial == 2
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	ca := newTestCert(t, 1, nil)
+	ca.write(t, caFile, "")
+	newTestCert(t, 2, ca).write(t, certFile, keyFile)
+
+	v := viper.New()
+	v.Set("http.host", "127.0.0.1")
+	v.Set("http.tls.cert", certFile)
+	v.Set("http.tls.key", keyFile)
+	v.Set("http.tls.client.ca", caFile)
+
+	s := New(v)
+	serve(t, s)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	url := "https://" + s.Addr().String() + "/ping"
+
+	is := assert.New(t)
+
+	anonymous := &http.Client{Timeout: 15 * time.Second, Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	_, err := get(anonymous, url)
+	is.Error(err)
+
+	client := &http.Client{Timeout: 15 * time.Second, Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      roots,
+		Certificates: []tls.Certificate{newTestCert(t, 3, ca).pair},
+	}}}
+	body, err := get(client, url)
+	is.NoError(err)
+	is.Equal("pong", body)
+}
+
+func TestInvalidClientAuth(t *testing.T) {
+	v := viper.New()
+	v.Set("http.tls.cert", "tls.crt")
+	v.Set("http.tls.key", "tls.key")
+	v.Set("http.tls.client.auth", "sometimes")
+
+	err := New(v).Run("127.0.0.1:0")
+
+	is := assert.New(t)
+	is.ErrorIs(err, ErrInvalidClientAuth)
+}
@@ -0,0 +1,16 @@
+package msgrouter
+
+import "time"
+
+type option interface{ apply(*Router) }
+type optionFunc func(*Router)
+
+func (fn optionFunc) apply(r *Router) { fn(r) }
+
+// WithWatchdog makes HealthCheck fail while a handler has been processing a
+// single message for longer than threshold.
+func WithWatchdog(threshold time.Duration) option {
+	return optionFunc(func(r *Router) {
+		r.watchdog = &watchdog{threshold: threshold}
+	})
+}
@@ -2,6 +2,7 @@ package msgrouter
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	"github.com/ThreeDotsLabs/watermill"
@@ -22,6 +23,7 @@ type Router struct {
 	manager *manager.Manager
 
 	metricBuilder metrics.Builder
+	watchdog      *watchdog
 }
 
 func (r *Router) Init() {
@@ -33,6 +35,9 @@ func (r *Router) Init() {
 		}
 
 		r.AddMiddleware(middleware.Recoverer)
+		if r.watchdog != nil {
+			r.AddMiddleware(r.watchdog.Middleware)
+		}
 
 		r.metricBuilder = metrics.NewOpenTelemetryMetricsBuilder(otelx.Meter(), "", "")
 		r.AddMiddleware(opentelemetry.HandlerMiddleware(r.metricBuilder))
@@ -68,9 +73,41 @@ func (r *Router) Run(ctx context.Context) error {
 	return r.Router.Run(ctx)
 }
 
+// Running returns a channel closed once the router runs its handlers.
+func (r *Router) Running() chan struct{} {
+	r.Init()
+	return r.Router.Running()
+}
+
+// Shutdown closes the router and waits for the running handlers until ctx is
+// done.
 func (r *Router) Shutdown(ctx context.Context) error {
 	r.Init()
-	return r.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- r.Close() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("core/msgrouter: %w", ctx.Err())
+	}
+}
+
+// HealthCheck implements provider.HealthChecker. It fails while a handler is
+// stuck on a message, see WithWatchdog.
+func (r *Router) HealthCheck(ctx context.Context) error {
+	if r.watchdog == nil {
+		return nil
+	}
+	return r.watchdog.HealthCheck(ctx)
 }
 
-func New() *Router { return &Router{} }
+func New(opts ...option) *Router {
+	r := &Router{}
+	for _, opt := range opts {
+		opt.apply(r)
+	}
+	return r
+}
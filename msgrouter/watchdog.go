@@ -0,0 +1,77 @@
+package msgrouter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// watchdog tracks the messages being handled, so that a handler stuck on a
+// message for longer than threshold can be reported.
+type watchdog struct {
+	threshold time.Duration
+
+	mu       sync.Mutex
+	seq      uint64
+	inflight map[uint64]handling
+}
+
+type handling struct {
+	handler string
+	started time.Time
+}
+
+func (w *watchdog) Middleware(h message.HandlerFunc) message.HandlerFunc {
+	return func(msg *message.Message) ([]*message.Message, error) {
+		id := w.begin(message.HandlerNameFromCtx(msg.Context()))
+		defer w.end(id)
+
+		return h(msg)
+	}
+}
+
+func (w *watchdog) begin(handler string) uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.inflight == nil {
+		w.inflight = map[uint64]handling{}
+	}
+
+	w.seq++
+	w.inflight[w.seq] = handling{handler: handler, started: time.Now()}
+	return w.seq
+}
+
+func (w *watchdog) end(id uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.inflight, id)
+}
+
+// HealthCheck reports the longest running handler if it exceeds threshold.
+func (w *watchdog) HealthCheck(context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var oldest handling
+	for _, v := range w.inflight {
+		if oldest.started.IsZero() || v.started.Before(oldest.started) {
+			oldest = v
+		}
+	}
+
+	if oldest.started.IsZero() {
+		return nil
+	}
+
+	if d := time.Since(oldest.started); d > w.threshold {
+		return fmt.Errorf("core/msgrouter: handler %s has been running for %s", oldest.handler, d.Round(time.Second))
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package msgrouter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchdog(t *testing.T) {
+	w := &watchdog{threshold: 20 * time.Millisecond}
+	is := assert.New(t)
+
+	is.NoError(w.HealthCheck(context.Background()))
+
+	id := w.begin("orders")
+	is.NoError(w.HealthCheck(context.Background()))
+
+	time.Sleep(30 * time.Millisecond)
+	is.ErrorContains(w.HealthCheck(context.Background()), "core/msgrouter: handler orders has been running for")
+
+	w.end(id)
+	is.NoError(w.HealthCheck(context.Background()))
+}
+
+func TestWatchdogMiddleware(t *testing.T) {
+	w := &watchdog{threshold: 20 * time.Millisecond}
+	is := assert.New(t)
+
+	release := make(chan struct{})
+	done := make(chan struct{})
+	h := w.Middleware(func(*message.Message) ([]*message.Message, error) {
+		<-release
+		return nil, nil
+	})
+
+	go func() {
+		defer close(done)
+		_, _ = h(message.NewMessage("1", nil))
+	}()
+
+	is.Eventually(func() bool { return w.HealthCheck(context.Background()) != nil }, time.Second, 5*time.Millisecond)
+
+	close(release)
+	<-done
+	is.NoError(w.HealthCheck(context.Background()))
+}
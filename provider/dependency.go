@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrDuplicateName     = errors.New("core/provider: duplicate provider name")
+	ErrUnknownDependency = errors.New("core/provider: unknown dependency")
+	ErrDependencyCycle   = errors.New("core/provider: dependency cycle")
+)
+
+func nameOf(p ContextProvider) string {
+	if n, ok := origin(p).(NamedProvider); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", origin(p))
+}
+
+func dependsOn(p ContextProvider) []string {
+	if d, ok := origin(p).(DependentProvider); ok {
+		return d.DependsOn()
+	}
+	return nil
+}
+
+// sortByDependency returns the indexes of the providers in boot order.
+// Providers without dependencies between them keep their registration order.
+func sortByDependency(ps []ContextProvider) ([]int, error) {
+	index := make(map[string]int, len(ps))
+	for i, p := range ps {
+		n, ok := origin(p).(NamedProvider)
+		if !ok {
+			continue
+		}
+
+		if _, ok := index[n.Name()]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateName, n.Name())
+		}
+		index[n.Name()] = i
+	}
+
+	deps := make([][]int, len(ps))
+	indegree := make([]int, len(ps))
+	dependents := make([][]int, len(ps))
+	for i, p := range ps {
+		for _, name := range dependsOn(p) {
+			j, ok := index[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s depends on %s", ErrUnknownDependency, nameOf(p), name)
+			}
+
+			deps[i] = append(deps[i], j)
+			indegree[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	sorted := make([]int, 0, len(ps))
+	done := make([]bool, len(ps))
+	for len(sorted) < len(ps) {
+		next := -1
+		for i := range ps {
+			if !done[i] && indegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+
+		if next < 0 {
+			return nil, cycleError(ps, deps, done)
+		}
+
+		done[next] = true
+		sorted = append(sorted, next)
+		for _, i := range dependents[next] {
+			indegree[i]--
+		}
+	}
+
+	return sorted, nil
+}
+
+// cycleError walks the dependencies of the providers left unsorted, every one
+// of which still waits on another unsorted provider, until a provider repeats.
+func cycleError(ps []ContextProvider, deps [][]int, done []bool) error {
+	start := 0
+	for done[start] {
+		start++
+	}
+
+	seen := map[int]int{}
+	path := []int{}
+	for i := start; ; {
+		if at, ok := seen[i]; ok {
+			path = append(path[at:], i)
+			break
+		}
+
+		seen[i] = len(path)
+		path = append(path, i)
+		for _, j := range deps[i] {
+			if !done[j] {
+				i = j
+				break
+			}
+		}
+	}
+
+	names := make([]string, len(path))
+	for k, i := range path {
+		names[k] = nameOf(ps[i])
+	}
+
+	return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(names, " -> "))
+}
@@ -0,0 +1,54 @@
+package k8s
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WithDrainEndpoint mounts __kube/drain next to the probes. GET reports the
+// drain state, POST drains and DELETE undrains the pod. The endpoint has no
+// authentication of its own; combine it with WithManagementServer to keep it
+// off the public listener.
+func WithDrainEndpoint() option {
+	return optionFunc(func(p *Provider) {
+		p.drainEndpoint = true
+	})
+}
+
+// Drain makes the readiness probe fail, taking the pod out of rotation
+// without stopping it.
+func (p *Provider) Drain() {
+	if !p.drained.Swap(true) {
+		slog.Info("core/provider/feature/k8s: pod drained")
+	}
+}
+
+// Undrain puts a drained pod back into rotation.
+func (p *Provider) Undrain() {
+	if p.drained.Swap(false) {
+		slog.Info("core/provider/feature/k8s: pod undrained")
+	}
+}
+
+// Drained reports whether the pod has been drained.
+func (p *Provider) Drained() bool { return p.drained.Load() }
+
+type drainState struct {
+	Drained bool `json:"drained"`
+}
+
+func (p *Provider) drainState(ctx *gin.Context) {
+	ctx.AbortWithStatusJSON(http.StatusOK, drainState{Drained: p.Drained()})
+}
+
+func (p *Provider) drain(ctx *gin.Context) {
+	p.Drain()
+	p.drainState(ctx)
+}
+
+func (p *Provider) undrain(ctx *gin.Context) {
+	p.Undrain()
+	p.drainState(ctx)
+}
@@ -0,0 +1,110 @@
+package k8s
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cyg-pd/go-core/provider"
+)
+
+const (
+	statusPass = "pass"
+	statusFail = "fail"
+)
+
+type checkResult struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+type healthReport struct {
+	Status  string                 `json:"status"`
+	Drained bool                   `json:"drained,omitempty"`
+	Checks  map[string]checkResult `json:"checks,omitempty"`
+}
+
+func (r healthReport) passed() bool { return r.Status == statusPass }
+
+// healthCache runs a set of health checks in parallel and keeps the report
+// for ttl, so frequent probes do not hammer the checked resources.
+type healthCache struct {
+	checks  func() map[string]provider.HealthChecker
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	report  healthReport
+	checked time.Time
+}
+
+func (h *healthCache) Report(ctx context.Context) healthReport {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.checked.IsZero() && time.Since(h.checked) < h.ttl {
+		return h.report
+	}
+
+	h.report = h.run(context.WithoutCancel(ctx))
+	h.checked = time.Now()
+	return h.report
+}
+
+func (h *healthCache) run(ctx context.Context) healthReport {
+	checks := h.checks()
+	report := healthReport{
+		Status: statusPass,
+		Checks: make(map[string]checkResult, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res := h.check(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = res
+			if res.Status != statusPass {
+				report.Status = statusFail
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+// check runs a single check within the timeout. A check that ignores its
+// context is abandoned once the timeout passes.
+func (h *healthCache) check(ctx context.Context, check provider.HealthChecker) checkResult {
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check.HealthCheck(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := checkResult{Status: statusPass, Latency: time.Since(start).String()}
+	if err != nil {
+		res.Status = statusFail
+		res.Error = err.Error()
+	}
+	return res
+}
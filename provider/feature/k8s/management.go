@@ -0,0 +1,114 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func SetupFlags(f *pflag.FlagSet, v *viper.Viper) {
+	f.String("k8s-management-host", "", "Kubernetes probes listen host (e.g. 0.0.0.0)")
+	f.Uint("k8s-management-port", 0, "Kubernetes probes listen port (e.g. 8081)")
+
+	_ = v.BindPFlag("k8s.management.host", f.Lookup("k8s-management-host"))
+	_ = v.BindPFlag("k8s.management.port", f.Lookup("k8s-management-port"))
+}
+
+// ErrNoManagementPort is returned by Boot when the management server has no
+// k8s.management.port to listen on.
+var ErrNoManagementPort = errors.New("core/provider/feature/k8s: k8s.management.port is not set")
+
+// WithManagementServer serves the probes on a dedicated HTTP server listening
+// on k8s.management.host and k8s.management.port, instead of the business
+// router. The probes then skip the router middleware and keep answering
+// while the business server drains. Boot fails when the port is not set.
+func WithManagementServer(v *viper.Viper) option {
+	return optionFunc(func(p *Provider) {
+		p.management = &managementServer{
+			host: v.GetString("k8s.management.host"),
+			port: v.GetInt("k8s.management.port"),
+		}
+		p.httpRouter = p.management.engine()
+	})
+}
+
+type managementServer struct {
+	host   string
+	port   int
+	router *gin.Engine
+
+	// mu guards server and addr, set by Start.
+	mu     sync.Mutex
+	server *http.Server
+	addr   string
+}
+
+func (m *managementServer) engine() *gin.Engine {
+	if m.router == nil {
+		m.router = gin.New()
+	}
+	return m.router
+}
+
+func (m *managementServer) Start() error {
+	if m.port == 0 {
+		return ErrNoManagementPort
+	}
+
+	ln, err := net.Listen("tcp", net.JoinHostPort(m.host, strconv.Itoa(m.port)))
+	if err != nil {
+		return fmt.Errorf("core/provider/feature/k8s: %w", err)
+	}
+
+	server := &http.Server{
+		Handler:           m.router,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	m.mu.Lock()
+	m.server, m.addr = server, ln.Addr().String()
+	m.mu.Unlock()
+
+	slog.Info("core/provider/feature/k8s: serving probes on " + ln.Addr().String())
+	go func() {
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			slog.Error("core/provider/feature/k8s: " + err.Error())
+		}
+	}()
+
+	return nil
+}
+
+// Addr returns the address the server listens on, or an empty string until
+// it is started.
+func (m *managementServer) Addr() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.addr
+}
+
+func (m *managementServer) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	server := m.server
+	m.mu.Unlock()
+
+	if server == nil {
+		return nil
+	}
+
+	if err := server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("core/provider/feature/k8s: %w", err)
+	}
+	return nil
+}
@@ -1,12 +1,14 @@
 package k8s
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"sync/atomic"
 	"time"
 
 	"github.com/cyg-pd/go-core/httprouter"
+	"github.com/cyg-pd/go-core/provider"
 	"github.com/gin-gonic/gin"
 )
 
@@ -21,50 +23,183 @@ func WithTerminateDelay(delay time.Duration) option {
 	})
 }
 
+// WithRegistry sets the registry whose providers implementing
+// provider.HealthChecker are checked by the readiness probe, and whose boot
+// is awaited by the startup probe. Defaults to the registry the provider is
+// registered in.
+func WithRegistry(r *provider.Registry) option {
+	return optionFunc(func(p *Provider) {
+		p.registry.Store(r)
+		p.fixedRegistry = true
+	})
+}
+
+// WithHealthCheckTimeout bounds each health check run by the probes.
+func WithHealthCheckTimeout(timeout time.Duration) option {
+	return optionFunc(func(p *Provider) {
+		p.checkTimeout = timeout
+	})
+}
+
+// WithHealthCheckCacheTTL sets how long the probes reuse health check results.
+func WithHealthCheckCacheTTL(ttl time.Duration) option {
+	return optionFunc(func(p *Provider) {
+		p.checkTTL = ttl
+	})
+}
+
+// WithWarmUp keeps the startup probe failing for delay after boot.
+func WithWarmUp(delay time.Duration) option {
+	return optionFunc(func(p *Provider) {
+		p.warmUp = delay
+	})
+}
+
+// WithLivenessCheck adds a check run by the liveness probe. A failing
+// liveness check makes Kubernetes restart the pod, so it should only detect
+// states the process cannot recover from, such as a stuck msgrouter.Router.
+func WithLivenessCheck(name string, check provider.HealthChecker) option {
+	return optionFunc(func(p *Provider) {
+		p.livenessChecks[name] = check
+	})
+}
+
+// WithStallDetection adds a liveness check failing when goroutines are not
+// scheduled for longer than threshold.
+func WithStallDetection(threshold time.Duration) option {
+	return optionFunc(func(p *Provider) {
+		p.stall = &stallDetector{threshold: threshold}
+		p.livenessChecks["stall"] = p.stall
+	})
+}
+
 func New(r httprouter.Router, opts ...option) *Provider {
 	p := &Provider{
 		httpRouter:     r,
 		terminateDelay: time.Second * 3,
+		checkTimeout:   time.Second,
+		checkTTL:       time.Second,
+		livenessChecks: map[string]provider.HealthChecker{},
 	}
+	p.registry.Store(provider.Default())
 	for _, opt := range opts {
 		opt.apply(p)
 	}
+
+	p.readinessCache = &healthCache{
+		checks:  func() map[string]provider.HealthChecker { return p.registry.Load().HealthCheckers() },
+		timeout: p.checkTimeout,
+		ttl:     p.checkTTL,
+	}
+	p.livenessCache = &healthCache{
+		checks:  func() map[string]provider.HealthChecker { return p.livenessChecks },
+		timeout: p.checkTimeout,
+		ttl:     p.checkTTL,
+	}
 	return p
 }
 
 // Provider 實作 K8s 相關功能
+//
+// It implements provider.ContextMethods, so the registry passes its deadlines
+// to BootContext and ShutdownContext.
 type Provider struct {
 	httpRouter httprouter.Router
 
 	terminateDelay time.Duration
 
+	registry      atomic.Pointer[provider.Registry]
+	fixedRegistry bool
+	checkTimeout  time.Duration
+	checkTTL      time.Duration
+	warmUp        time.Duration
+
+	readinessCache *healthCache
+	livenessCache  *healthCache
+	livenessChecks map[string]provider.HealthChecker
+	stall          *stallDetector
+	management     *managementServer
+	drainEndpoint  bool
+
+	bootAt  atomic.Int64
 	initial atomic.Bool
 	ready   atomic.Bool
+	drained atomic.Bool
+}
+
+// SetRegistry implements provider.RegistryAware.
+func (p *Provider) SetRegistry(r *provider.Registry) {
+	if !p.fixedRegistry {
+		p.registry.Store(r)
+	}
 }
 
-// Boot implements provider.Provider.
-func (p *Provider) Boot() error {
+// Boot implements provider.Provider, see BootContext.
+func (p *Provider) Boot() error { return p.BootContext(context.Background()) }
+
+// BootContext implements provider.ContextMethods.
+func (p *Provider) BootContext(context.Context) error {
 	if p.httpRouter == nil {
 		return nil
 	}
 
+	p.bootAt.Store(time.Now().UnixNano())
 	p.ready.Store(true)
+	if p.stall != nil {
+		p.stall.Start()
+	}
 
+	p.httpRouter.GET("__kube/startup", p.startup)
 	p.httpRouter.GET("__kube/readiness", p.readiness)
 	p.httpRouter.GET("__kube/liveness", p.liveness)
 
+	if p.drainEndpoint {
+		p.httpRouter.GET("__kube/drain", p.drainState)
+		p.httpRouter.POST("__kube/drain", p.drain)
+		p.httpRouter.DELETE("__kube/drain", p.undrain)
+	}
+
+	if p.management != nil {
+		return p.management.Start()
+	}
+
 	return nil
 }
 
+// ManagementAddr returns the address the management server listens on, or
+// an empty string when the probes are served by the business router.
+func (p *Provider) ManagementAddr() string {
+	if p.management == nil {
+		return ""
+	}
+	return p.management.Addr()
+}
+
 func (p *Provider) readiness(ctx *gin.Context) {
 	if !p.initial.Load() {
 		p.initial.Store(true)
 	}
 
-	if p.ready.Load() {
-		ctx.AbortWithStatus(http.StatusOK)
+	if !p.ready.Load() {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, healthReport{Status: statusFail})
+		return
+	}
+
+	if p.Drained() {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, healthReport{Status: statusFail, Drained: true})
+		return
+	}
+
+	p.renderReport(ctx, p.readinessCache.Report(ctx.Request.Context()))
+}
+
+// startup passes once every provider has booted and the warm-up is over.
+func (p *Provider) startup(ctx *gin.Context) {
+	warm := time.Since(time.Unix(0, p.bootAt.Load())) >= p.warmUp
+	if p.registry.Load().Booted() && warm {
+		ctx.AbortWithStatusJSON(http.StatusOK, healthReport{Status: statusPass})
 	} else {
-		ctx.AbortWithStatus(http.StatusInternalServerError)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, healthReport{Status: statusFail})
 	}
 }
 
@@ -73,19 +208,74 @@ func (p *Provider) liveness(ctx *gin.Context) {
 		p.initial.Store(true)
 	}
 
-	ctx.AbortWithStatus(http.StatusOK)
+	p.renderReport(ctx, p.livenessCache.Report(ctx.Request.Context()))
 }
 
-func (p *Provider) Shutdown() error {
+func (p *Provider) renderReport(ctx *gin.Context, report healthReport) {
+	if report.passed() {
+		ctx.AbortWithStatusJSON(http.StatusOK, report)
+	} else {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, report)
+	}
+}
+
+// PreStop implements provider.PreStopper. It fails the readiness probe and
+// waits for Kubernetes to take the pod out of rotation.
+func (p *Provider) PreStop(ctx context.Context) error {
 	if p.httpRouter == nil {
 		return nil
 	}
 
-	p.ready.Store(false)
+	p.unready(ctx)
+	return nil
+}
+
+// unready fails the readiness probe. The first call waits terminateDelay
+// when running in Kubernetes and the probes have been hit.
+func (p *Provider) unready(ctx context.Context) {
+	if !p.ready.Swap(false) {
+		return
+	}
 
 	if os.Getenv("KUBERNETES_PORT") != "" && p.initial.Load() {
-		<-time.After(p.terminateDelay)
+		select {
+		case <-time.After(p.terminateDelay):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// Shutdown implements provider.Provider. It is ShutdownContext bounded by
+// five seconds.
+func (p *Provider) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return p.ShutdownContext(ctx)
+}
+
+// ShutdownContext implements provider.ContextMethods. The management server
+// drains its requests until ctx is done, bounded by the provider deadlines
+// of the registry, see provider.WithTimeout.
+func (p *Provider) ShutdownContext(ctx context.Context) error {
+	if p.httpRouter == nil {
+		return nil
+	}
+
+	p.unready(ctx)
+	if p.stall != nil {
+		p.stall.Stop()
+	}
+
+	if p.management != nil {
+		return p.management.Shutdown(ctx)
 	}
 
 	return nil
 }
+
+var (
+	_ provider.Provider       = (*Provider)(nil)
+	_ provider.ContextMethods = (*Provider)(nil)
+	_ provider.PreStopper     = (*Provider)(nil)
+	_ provider.RegistryAware  = (*Provider)(nil)
+)
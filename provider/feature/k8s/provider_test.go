@@ -0,0 +1,212 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cyg-pd/go-core/provider"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type checkedProvider struct {
+	provider.NoopProvider
+	name string
+	err  error
+}
+
+func (c *checkedProvider) Name() string                          { return c.name }
+func (c *checkedProvider) HealthCheck(ctx context.Context) error { return c.err }
+
+type hangingProvider struct{ provider.NoopProvider }
+
+func (h *hangingProvider) Name() string { return "hanging" }
+func (h *hangingProvider) HealthCheck(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func probe(r http.Handler, path string) (int, healthReport) {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var report healthReport
+	_ = json.Unmarshal(w.Body.Bytes(), &report)
+	return w.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	reg := provider.NewRegistry()
+	reg.Register(&checkedProvider{name: "db"})
+
+	p := New(router, WithRegistry(reg))
+	reg.Register(p)
+
+	is := assert.New(t)
+	is.NoError(reg.Boot(context.Background()))
+
+	code, report := probe(router, "/__kube/readiness")
+	is.Equal(http.StatusOK, code)
+	is.Equal(statusPass, report.Status)
+	is.Equal(statusPass, report.Checks["db"].Status)
+	is.NotEmpty(report.Checks["db"].Latency)
+}
+
+func TestReadinessFailingCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	reg := provider.NewRegistry()
+	reg.Register(&checkedProvider{name: "db", err: errors.New("connection refused")})
+	reg.Register(&hangingProvider{})
+
+	p := New(router, WithRegistry(reg), WithHealthCheckTimeout(10*time.Millisecond))
+	reg.Register(p)
+
+	is := assert.New(t)
+	is.NoError(reg.Boot(context.Background()))
+
+	code, report := probe(router, "/__kube/readiness")
+	is.Equal(http.StatusInternalServerError, code)
+	is.Equal(statusFail, report.Status)
+	is.Equal("connection refused", report.Checks["db"].Error)
+	is.Equal(context.DeadlineExceeded.Error(), report.Checks["hanging"].Error)
+}
+
+func TestStartupWarmUp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	reg := provider.NewRegistry()
+	p := New(router, WithRegistry(reg), WithWarmUp(50*time.Millisecond))
+	reg.Register(p)
+
+	is := assert.New(t)
+	is.NoError(reg.Boot(context.Background()))
+
+	code, _ := probe(router, "/__kube/startup")
+	is.Equal(http.StatusInternalServerError, code)
+
+	time.Sleep(50 * time.Millisecond)
+	code, _ = probe(router, "/__kube/startup")
+	is.Equal(http.StatusOK, code)
+}
+
+func TestRegisteredRegistry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	reg := provider.NewRegistry()
+	reg.Register(&checkedProvider{name: "db"})
+	reg.Register(New(router))
+
+	is := assert.New(t)
+	is.NoError(reg.Boot(context.Background()))
+
+	code, _ := probe(router, "/__kube/startup")
+	is.Equal(http.StatusOK, code)
+
+	code, report := probe(router, "/__kube/readiness")
+	is.Equal(http.StatusOK, code)
+	is.Contains(report.Checks, "db")
+}
+
+func TestLivenessCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	reg := provider.NewRegistry()
+	reg.Register(&checkedProvider{name: "db", err: errors.New("connection refused")})
+
+	wedged := provider.HealthCheckFunc(func(context.Context) error { return errors.New("wedged") })
+	p := New(router, WithRegistry(reg), WithLivenessCheck("loop", wedged))
+	reg.Register(p)
+
+	is := assert.New(t)
+	is.NoError(reg.Boot(context.Background()))
+
+	code, report := probe(router, "/__kube/liveness")
+	is.Equal(http.StatusInternalServerError, code)
+	is.Equal("wedged", report.Checks["loop"].Error)
+	is.NotContains(report.Checks, "db")
+}
+
+func TestManagementServer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+
+	v := viper.New()
+	v.Set("k8s.management.host", "127.0.0.1")
+	v.Set("k8s.management.port", port)
+
+	reg := provider.NewRegistry()
+	p := New(router, WithRegistry(reg), WithManagementServer(v), WithTerminateDelay(0))
+	reg.Register(p)
+
+	is := assert.New(t)
+	is.NoError(reg.Boot(context.Background()))
+	defer func() { is.NoError(reg.Shutdown(context.Background())) }()
+
+	code, _ := probe(router, "/__kube/readiness")
+	is.Equal(http.StatusNotFound, code)
+
+	res, err := http.Get("http://" + p.ManagementAddr() + "/__kube/readiness")
+	is.NoError(err)
+	_ = res.Body.Close()
+	is.Equal(http.StatusOK, res.StatusCode)
+}
+
+func TestManagementServerWithoutPort(t *testing.T) {
+	v := viper.New()
+	v.Set("k8s.management.host", "127.0.0.1")
+
+	reg := provider.NewRegistry()
+	reg.Register(New(gin.New(), WithRegistry(reg), WithManagementServer(v)))
+
+	assert.ErrorIs(t, reg.Boot(context.Background()), ErrNoManagementPort)
+}
+
+func TestDrain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	reg := provider.NewRegistry()
+	p := New(router, WithRegistry(reg), WithDrainEndpoint())
+	reg.Register(p)
+
+	is := assert.New(t)
+	is.NoError(reg.Boot(context.Background()))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/__kube/drain", nil))
+	is.JSONEq(`{"drained":true}`, w.Body.String())
+	is.True(p.Drained())
+
+	code, report := probe(router, "/__kube/readiness")
+	is.Equal(http.StatusInternalServerError, code)
+	is.True(report.Drained)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/__kube/drain", nil))
+	is.JSONEq(`{"drained":false}`, w.Body.String())
+
+	code, _ = probe(router, "/__kube/readiness")
+	is.Equal(http.StatusOK, code)
+}
@@ -0,0 +1,61 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const stallTickInterval = 100 * time.Millisecond
+
+// stallDetector keeps a goroutine ticking and fails its check when a tick
+// arrives much later than scheduled, which happens when the process is
+// wedged and no longer schedules goroutines on time.
+type stallDetector struct {
+	threshold time.Duration
+
+	running  atomic.Bool
+	lastTick atomic.Int64
+	stop     chan struct{}
+}
+
+func (s *stallDetector) Start() {
+	s.lastTick.Store(time.Now().UnixNano())
+	s.stop = make(chan struct{})
+	s.running.Store(true)
+
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(stallTickInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.lastTick.Store(time.Now().UnixNano())
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+func (s *stallDetector) Stop() {
+	if s.stop != nil {
+		s.running.Store(false)
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+func (s *stallDetector) HealthCheck(context.Context) error {
+	if !s.running.Load() {
+		return nil
+	}
+
+	lag := time.Since(time.Unix(0, s.lastTick.Load())) - stallTickInterval
+	if lag > s.threshold {
+		return fmt.Errorf("core/provider/feature/k8s: goroutines stalled for %s", lag.Round(time.Millisecond))
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStallDetector(t *testing.T) {
+	s := &stallDetector{threshold: 50 * time.Millisecond}
+	is := assert.New(t)
+
+	// Not running.
+	is.NoError(s.HealthCheck(context.Background()))
+
+	s.running.Store(true)
+	s.lastTick.Store(time.Now().UnixNano())
+	is.NoError(s.HealthCheck(context.Background()))
+
+	s.lastTick.Store(time.Now().Add(-time.Second).UnixNano())
+	is.ErrorContains(s.HealthCheck(context.Background()), "goroutines stalled for")
+}
+
+func TestStallDetectorStartStop(t *testing.T) {
+	s := &stallDetector{threshold: 50 * time.Millisecond}
+	is := assert.New(t)
+
+	s.Start()
+	time.Sleep(2 * stallTickInterval)
+	is.NoError(s.HealthCheck(context.Background()))
+
+	s.Stop()
+	s.Stop()
+	s.lastTick.Store(time.Now().Add(-time.Second).UnixNano())
+	is.NoError(s.HealthCheck(context.Background()))
+}
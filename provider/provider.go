@@ -1,6 +1,95 @@
 package provider
 
+import "context"
+
+// Provider is booted and shut down by a Registry. A Provider may also
+// implement BootContext and ShutdownContext, see ContextMethods, which the
+// registry calls instead to pass its deadlines.
 type Provider interface {
 	Boot() error
 	Shutdown() error
 }
+
+// ContextProvider is a Provider whose lifecycle methods receive a context
+// carrying the boot or shutdown deadline. Register it with RegisterContext.
+//
+// A provider should return once its context is done. Otherwise the registry
+// abandons it at the deadline, and shuts it down if its boot succeeds later.
+type ContextProvider interface {
+	Boot(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+}
+
+// ContextMethods is implemented by a Provider offering the methods of a
+// ContextProvider under other names, so it stays registrable with Register.
+type ContextMethods interface {
+	BootContext(ctx context.Context) error
+	ShutdownContext(ctx context.Context) error
+}
+
+// NamedProvider is implemented by providers that can be referenced by other
+// providers through their name.
+type NamedProvider interface {
+	Name() string
+}
+
+// DependentProvider is implemented by providers that must be booted after,
+// and shut down before, the named providers they depend on.
+type DependentProvider interface {
+	DependsOn() []string
+}
+
+// HealthChecker is implemented by providers that can report whether the
+// resources they manage are usable.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// PreStopper is implemented by providers that must act before the
+// application stops accepting work, such as failing readiness probes.
+type PreStopper interface {
+	PreStop(ctx context.Context) error
+}
+
+// RegistryAware is implemented by providers acting on the registry they are
+// registered in, such as probes checking the other providers. SetRegistry is
+// called on registration.
+type RegistryAware interface {
+	SetRegistry(r *Registry)
+}
+
+// HealthCheckFunc adapts an ordinary function to a HealthChecker.
+type HealthCheckFunc func(ctx context.Context) error
+
+func (f HealthCheckFunc) HealthCheck(ctx context.Context) error { return f(ctx) }
+
+// plainProvider adapts a Provider to a ContextProvider.
+type plainProvider struct{ p Provider }
+
+func (p plainProvider) Boot(ctx context.Context) error {
+	if c, ok := p.p.(ContextMethods); ok {
+		return c.BootContext(ctx)
+	}
+	return p.p.Boot()
+}
+
+func (p plainProvider) Shutdown(ctx context.Context) error {
+	if c, ok := p.p.(ContextMethods); ok {
+		return c.ShutdownContext(ctx)
+	}
+	return p.p.Shutdown()
+}
+
+// contextProvider adapts a ContextProvider to a Provider.
+type contextProvider struct{ p ContextProvider }
+
+func (p contextProvider) Boot() error     { return p.p.Boot(context.Background()) }
+func (p contextProvider) Shutdown() error { return p.p.Shutdown(context.Background()) }
+
+// origin returns the value that was registered.
+func origin(p ContextProvider) any {
+	if v, ok := p.(plainProvider); ok {
+		return v.p
+	}
+	return p
+}
@@ -0,0 +1,333 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type option interface{ apply(*Registry) }
+type optionFunc func(*Registry)
+
+func (fn optionFunc) apply(r *Registry) { fn(r) }
+
+// Default deadlines of Boot and Shutdown, see WithTimeout.
+const (
+	DefaultProviderTimeout = 30 * time.Second
+	DefaultTotalTimeout    = 2 * time.Minute
+)
+
+// WithTimeout sets the per-provider and overall deadlines of Boot and
+// Shutdown, DefaultProviderTimeout and DefaultTotalTimeout by default. Zero
+// opts out: no limit other than the deadline of the context passed in.
+func WithTimeout(perProvider, overall time.Duration) option {
+	return optionFunc(func(r *Registry) {
+		r.providerTimeout, r.totalTimeout = perProvider, overall
+	})
+}
+
+func NewRegistry(opts ...option) *Registry {
+	r := &Registry{
+		providerTimeout: DefaultProviderTimeout,
+		totalTimeout:    DefaultTotalTimeout,
+	}
+	for _, opt := range opts {
+		opt.apply(r)
+	}
+	return r
+}
+
+// Registry holds a set of providers and drives their lifecycle.
+type Registry struct {
+	// lifecycle serializes Boot, Shutdown and the boot of providers
+	// registered afterwards. mu guards the fields below and is never held
+	// while a provider runs, so providers may register other providers.
+	lifecycle sync.Mutex
+	mu        sync.Mutex
+
+	providers []ContextProvider
+	booted    []int
+	isBoot    bool
+
+	providerTimeout time.Duration
+	totalTimeout    time.Duration
+}
+
+// SetTimeout sets the per-provider and overall deadlines of Boot and Shutdown,
+// see WithTimeout.
+func (r *Registry) SetTimeout(perProvider, overall time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.providerTimeout, r.totalTimeout = perProvider, overall
+}
+
+func (r *Registry) All() []Provider {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ps := make([]Provider, len(r.providers))
+	for i, p := range r.providers {
+		if v, ok := p.(plainProvider); ok {
+			ps[i] = v.p
+		} else {
+			ps[i] = contextProvider{p}
+		}
+	}
+	return ps
+}
+
+// Booted reports whether every registered provider has booted.
+func (r *Registry) Booted() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.isBoot
+}
+
+// HealthCheckers returns the registered providers implementing
+// HealthChecker, keyed by provider name.
+func (r *Registry) HealthCheckers() map[string]HealthChecker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	checkers := map[string]HealthChecker{}
+	for i, p := range r.providers {
+		c, ok := origin(p).(HealthChecker)
+		if !ok {
+			continue
+		}
+
+		name := nameOf(p)
+		if _, ok := checkers[name]; ok {
+			name = name + "#" + strconv.Itoa(i)
+		}
+		checkers[name] = c
+	}
+	return checkers
+}
+
+func (r *Registry) Register(p Provider) error { return r.RegisterContext(plainProvider{p}) }
+
+// RegisterContext adds p to the registry. If the registry has already
+// booted, p is booted right away, within the deadlines of the registry, and
+// removed if it fails the dependency checks or its boot.
+func (r *Registry) RegisterContext(p ContextProvider) error {
+	if a, ok := origin(p).(RegistryAware); ok {
+		a.SetRegistry(r)
+	}
+
+	r.mu.Lock()
+	isBoot := r.isBoot
+	if !isBoot {
+		r.providers = append(r.providers, p)
+	}
+	r.mu.Unlock()
+
+	if !isBoot {
+		return nil
+	}
+
+	r.lifecycle.Lock()
+	defer r.lifecycle.Unlock()
+
+	r.mu.Lock()
+	if !r.isBoot {
+		// Shut down in the meantime.
+		r.providers = append(r.providers, p)
+		r.mu.Unlock()
+		return nil
+	}
+
+	i := len(r.providers)
+	providers := append(slices.Clip(r.providers), p)
+	if _, err := sortByDependency(providers); err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	r.providers = providers
+	timeout, totalTimeout := r.providerTimeout, r.totalTimeout
+	r.mu.Unlock()
+
+	ctx := context.Background()
+	if totalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, totalTimeout)
+		defer cancel()
+	}
+
+	err := call(ctx, timeout, "boot", p, p.Boot)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.providers = slices.Delete(r.providers, i, i+1)
+		return err
+	}
+	r.booted = append(r.booted, i)
+	return nil
+}
+
+// Boot boots every registered provider after the providers it depends on.
+// It stops at the first provider that fails or runs out of time.
+func (r *Registry) Boot(ctx context.Context) error {
+	r.lifecycle.Lock()
+	defer r.lifecycle.Unlock()
+
+	r.mu.Lock()
+	isBoot, totalTimeout := r.isBoot, r.totalTimeout
+	r.mu.Unlock()
+
+	if isBoot {
+		return nil
+	}
+
+	if totalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, totalTimeout)
+		defer cancel()
+	}
+
+	return r.boot(ctx)
+}
+
+// boot boots the providers that are not booted yet, including the ones
+// registered while it runs.
+func (r *Registry) boot(ctx context.Context) error {
+	for {
+		r.mu.Lock()
+		order, err := sortByDependency(r.providers)
+		pending := slices.DeleteFunc(order, func(i int) bool { return slices.Contains(r.booted, i) })
+		providers, timeout := r.providers, r.providerTimeout
+		if err == nil && len(pending) == 0 {
+			r.isBoot = true
+		}
+		r.mu.Unlock()
+
+		if err != nil {
+			return err
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		for _, i := range pending {
+			p := providers[i]
+			if err := call(ctx, timeout, "boot", p, p.Boot); err != nil {
+				return err
+			}
+
+			r.mu.Lock()
+			r.booted = append(r.booted, i)
+			r.mu.Unlock()
+		}
+	}
+}
+
+// Shutdown shuts down the booted providers in reverse boot order.
+// Providers that fail or run out of time do not stop the remaining ones.
+func (r *Registry) Shutdown(ctx context.Context) error {
+	r.lifecycle.Lock()
+	defer r.lifecycle.Unlock()
+
+	r.mu.Lock()
+	providers, booted := r.providers, r.booted
+	timeout, totalTimeout := r.providerTimeout, r.totalTimeout
+	r.mu.Unlock()
+
+	if totalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, totalTimeout)
+		defer cancel()
+	}
+
+	errs := []error{}
+	for _, i := range slices.Backward(booted) {
+		p := providers[i]
+		errs = append(errs, call(ctx, timeout, "shutdown", p, p.Shutdown))
+	}
+
+	r.mu.Lock()
+	r.booted = nil
+	r.isBoot = false
+	r.mu.Unlock()
+
+	return errors.Join(errs...)
+}
+
+// PreStop runs PreStop on the booted providers implementing PreStopper, in
+// reverse boot order.
+func (r *Registry) PreStop(ctx context.Context) error {
+	r.mu.Lock()
+	providers, booted, timeout := r.providers, r.booted, r.providerTimeout
+	r.mu.Unlock()
+
+	errs := []error{}
+	for _, i := range slices.Backward(booted) {
+		p := providers[i]
+		if s, ok := origin(p).(PreStopper); ok {
+			errs = append(errs, call(ctx, timeout, "pre-stop", p, s.PreStop))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// call runs fn within the per-provider deadline. A provider that ignores its
+// context is abandoned once the deadline passes, so it cannot hang the caller.
+// An abandoned boot that succeeds later is shut down, so its resources do not
+// leak.
+func call(ctx context.Context, timeout time.Duration, phase string, p ContextProvider, fn func(context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("core/provider: %s %s: %w", phase, nameOf(p), err)
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- fn(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		if phase == "boot" {
+			go shutdownAbandoned(timeout, p, done)
+		}
+	}
+
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("core/provider: %s %s: timed out after %s: %w", phase, nameOf(p), time.Since(start).Round(time.Millisecond), err)
+		}
+		return fmt.Errorf("core/provider: %s %s: %w", phase, nameOf(p), err)
+	}
+
+	return nil
+}
+
+// shutdownAbandoned shuts p down once its abandoned boot succeeds.
+func shutdownAbandoned(timeout time.Duration, p ContextProvider, done <-chan error) {
+	if err := <-done; err != nil {
+		return
+	}
+
+	slog.Warn("core/provider: " + nameOf(p) + " booted after its deadline, shutting it down")
+	if err := call(context.Background(), timeout, "shutdown", p, p.Shutdown); err != nil {
+		slog.Error(err.Error())
+	}
+}
+
+var _ ContextProvider = (*Registry)(nil)
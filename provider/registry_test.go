@@ -0,0 +1,286 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockProvider struct {
+	name string
+	deps []string
+	log  *[]string
+}
+
+func (m *mockProvider) Name() string        { return m.name }
+func (m *mockProvider) DependsOn() []string { return m.deps }
+func (m *mockProvider) Boot() error         { *m.log = append(*m.log, "boot "+m.name); return nil }
+func (m *mockProvider) Shutdown() error     { *m.log = append(*m.log, "shutdown "+m.name); return nil }
+
+func TestBootDependencyOrder(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+
+	log := []string{}
+	r.Register(&mockProvider{name: "cache", deps: []string{"db"}, log: &log})
+	r.Register(&mockProvider{name: "db", log: &log})
+	r.Register(&mockProvider{name: "queue", log: &log})
+
+	is := assert.New(t)
+	is.NoError(r.Boot(context.Background()))
+	is.NoError(r.Shutdown(context.Background()))
+	is.Equal([]string{
+		"boot db", "boot cache", "boot queue",
+		"shutdown queue", "shutdown cache", "shutdown db",
+	}, log)
+}
+
+type preStopProvider struct{ mockProvider }
+
+func (p *preStopProvider) PreStop(context.Context) error {
+	*p.log = append(*p.log, "pre-stop "+p.name)
+	return nil
+}
+
+func TestPreStopOrder(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+
+	log := []string{}
+	r.Register(&preStopProvider{mockProvider{name: "cache", deps: []string{"db"}, log: &log}})
+	r.Register(&preStopProvider{mockProvider{name: "db", log: &log}})
+	r.Register(&mockProvider{name: "queue", log: &log})
+
+	is := assert.New(t)
+	is.NoError(r.PreStop(context.Background()))
+	is.Empty(log)
+
+	is.NoError(r.Boot(context.Background()))
+	is.NoError(r.PreStop(context.Background()))
+	is.Equal([]string{"boot db", "boot cache", "boot queue", "pre-stop cache", "pre-stop db"}, log)
+}
+
+func TestBootDependencyCycle(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+
+	log := []string{}
+	r.Register(&mockProvider{name: "a", deps: []string{"b"}, log: &log})
+	r.Register(&mockProvider{name: "b", deps: []string{"c"}, log: &log})
+	r.Register(&mockProvider{name: "c", deps: []string{"a"}, log: &log})
+
+	is := assert.New(t)
+	err := r.Boot(context.Background())
+	is.ErrorIs(err, ErrDependencyCycle)
+	is.EqualError(err, "core/provider: dependency cycle: a -> b -> c -> a")
+	is.Empty(log)
+}
+
+func TestBootUnknownDependency(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+
+	log := []string{}
+	r.Register(&mockProvider{name: "cache", deps: []string{"db"}, log: &log})
+
+	is := assert.New(t)
+	is.ErrorIs(r.Boot(context.Background()), ErrUnknownDependency)
+	is.Empty(log)
+}
+
+func TestBootDuplicateName(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+
+	log := []string{}
+	r.Register(&mockProvider{name: "db", log: &log})
+	r.Register(&mockProvider{name: "db", log: &log})
+
+	is := assert.New(t)
+	is.ErrorIs(r.Boot(context.Background()), ErrDuplicateName)
+}
+
+type hangingProvider struct{ NoopProvider }
+
+func (h *hangingProvider) Name() string { return "hanging" }
+func (h *hangingProvider) Shutdown() error {
+	select {}
+}
+
+type hangingBootProvider struct{ NoopProvider }
+
+func (h *hangingBootProvider) Boot() error {
+	select {}
+}
+
+type contextMockProvider struct{ ctx context.Context }
+
+func (c *contextMockProvider) Boot(ctx context.Context) error {
+	c.ctx = ctx
+	return nil
+}
+
+func (c *contextMockProvider) Shutdown(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestShutdownProviderTimeout(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry(WithTimeout(10*time.Millisecond, 0))
+
+	log := []string{}
+	r.Register(&mockProvider{name: "db", log: &log})
+	r.Register(&hangingProvider{})
+
+	is := assert.New(t)
+	is.NoError(r.Boot(context.Background()))
+
+	err := r.Shutdown(context.Background())
+	is.ErrorIs(err, context.DeadlineExceeded)
+	is.ErrorContains(err, "core/provider: shutdown hanging: timed out")
+	is.Equal([]string{"boot db", "shutdown db"}, log)
+}
+
+// slowBootProvider boots after delay, ignoring its context.
+type slowBootProvider struct {
+	delay    time.Duration
+	shutdown chan struct{}
+}
+
+func (s *slowBootProvider) Boot() error     { time.Sleep(s.delay); return nil }
+func (s *slowBootProvider) Shutdown() error { close(s.shutdown); return nil }
+
+func TestAbandonedBoot(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry(WithTimeout(10*time.Millisecond, 0))
+
+	p := &slowBootProvider{delay: 50 * time.Millisecond, shutdown: make(chan struct{})}
+	r.Register(p)
+
+	is := assert.New(t)
+	is.ErrorIs(r.Boot(context.Background()), context.DeadlineExceeded)
+
+	select {
+	case <-p.shutdown:
+	case <-time.After(time.Second):
+		t.Fatal("abandoned provider not shut down")
+	}
+}
+
+func TestDefaultTimeout(t *testing.T) {
+	t.Parallel()
+	is := assert.New(t)
+
+	r := NewRegistry()
+	is.Equal(DefaultProviderTimeout, r.providerTimeout)
+	is.Equal(DefaultTotalTimeout, r.totalTimeout)
+
+	r = NewRegistry(WithTimeout(0, 0))
+	is.Zero(r.providerTimeout)
+	is.Zero(r.totalTimeout)
+}
+
+func TestContextProvider(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+
+	p := &contextMockProvider{}
+	r.RegisterContext(p)
+
+	is := assert.New(t)
+	type key struct{}
+	is.NoError(r.Boot(context.WithValue(context.Background(), key{}, "value")))
+	is.Equal("value", p.ctx.Value(key{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	is.ErrorIs(r.Shutdown(ctx), context.DeadlineExceeded)
+}
+
+// contextMethodsProvider is a Provider with the methods of a
+// ContextProvider under other names.
+type contextMethodsProvider struct{ contextMockProvider }
+
+func (c *contextMethodsProvider) Boot() error     { return nil }
+func (c *contextMethodsProvider) Shutdown() error { return nil }
+
+func (c *contextMethodsProvider) BootContext(ctx context.Context) error {
+	return c.contextMockProvider.Boot(ctx)
+}
+
+func (c *contextMethodsProvider) ShutdownContext(ctx context.Context) error {
+	return c.contextMockProvider.Shutdown(ctx)
+}
+
+func TestContextMethods(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+
+	p := &contextMethodsProvider{}
+	r.Register(p)
+
+	is := assert.New(t)
+	type key struct{}
+	is.NoError(r.Boot(context.WithValue(context.Background(), key{}, "value")))
+	is.Equal("value", p.ctx.Value(key{}))
+	is.Same(p, r.All()[0])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	is.ErrorIs(r.Shutdown(ctx), context.DeadlineExceeded)
+}
+
+func TestRegisterAfterBoot(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+
+	log := []string{}
+	r.Register(&mockProvider{name: "db", log: &log})
+
+	is := assert.New(t)
+	is.NoError(r.Boot(context.Background()))
+
+	r.Register(&mockProvider{name: "cache", deps: []string{"db"}, log: &log})
+	is.NoError(r.Shutdown(context.Background()))
+	is.Equal([]string{"boot db", "boot cache", "shutdown cache", "shutdown db"}, log)
+	is.Len(r.All(), 2)
+}
+
+func TestRegisterAfterBootRejected(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry(WithTimeout(10*time.Millisecond, 0))
+
+	log := []string{}
+	r.Register(&mockProvider{name: "db", log: &log})
+
+	is := assert.New(t)
+	is.NoError(r.Boot(context.Background()))
+
+	err := r.Register(&mockProvider{name: "cache", deps: []string{"redis"}, log: &log})
+	is.ErrorIs(err, ErrUnknownDependency)
+	is.Len(r.All(), 1)
+
+	is.ErrorIs(r.Register(&hangingBootProvider{}), context.DeadlineExceeded)
+	is.Len(r.All(), 1)
+
+	late := &contextMockProvider{}
+	is.NoError(r.RegisterContext(late))
+	_, ok := late.ctx.Deadline()
+	is.True(ok)
+	is.Len(r.All(), 2)
+	is.True(r.Booted())
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	log := []string{}
+	Register(&mockProvider{name: "db", log: &log})
+
+	is := assert.New(t)
+	is.NoError(Boot())
+	is.NoError(Shutdown())
+	is.Equal([]string{"boot db", "shutdown db"}, log)
+	is.Same(Default(), defaultRegistry)
+}
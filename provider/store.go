@@ -1,48 +1,32 @@
 package provider
 
 import (
-	"errors"
-	"log/slog"
-	"sync"
+	"context"
+	"time"
 )
 
-var isBoot = false
+var defaultRegistry = NewRegistry()
 
-var provider = []Provider{}
-var mu sync.Mutex
+// Default returns the registry used by the package-level functions.
+func Default() *Registry { return defaultRegistry }
 
-func All() []Provider { return provider }
-func Register(p Provider) {
-	mu.Lock()
-	defer mu.Unlock()
-
-	provider = append(provider, p)
-	if isBoot {
-		if err := p.Boot(); err != nil {
-			slog.Error("boot: " + err.Error())
-		}
-	}
+// SetTimeout sets the per-provider and overall deadlines of Boot and Shutdown,
+// see WithTimeout.
+func SetTimeout(perProvider, overall time.Duration) {
+	defaultRegistry.SetTimeout(perProvider, overall)
 }
 
-func Boot() error {
-	if isBoot {
-		return nil
-	}
+func All() []Provider                         { return defaultRegistry.All() }
+func Register(p Provider) error               { return defaultRegistry.Register(p) }
+func RegisterContext(p ContextProvider) error { return defaultRegistry.RegisterContext(p) }
 
-	for _, provider := range provider {
-		if err := provider.Boot(); err != nil {
-			return err
-		}
-	}
+func Boot() error     { return BootContext(context.Background()) }
+func Shutdown() error { return ShutdownContext(context.Background()) }
 
-	isBoot = true
-	return nil
-}
+// BootContext boots every registered provider after the providers it depends
+// on. It stops at the first provider that fails or runs out of time.
+func BootContext(ctx context.Context) error { return defaultRegistry.Boot(ctx) }
 
-func Shutdown() error {
-	errs := []error{}
-	for _, v := range provider {
-		errs = append(errs, v.Shutdown())
-	}
-	return errors.Join(errs...)
-}
+// ShutdownContext shuts down the booted providers in reverse boot order.
+// Providers that fail or run out of time do not stop the remaining ones.
+func ShutdownContext(ctx context.Context) error { return defaultRegistry.Shutdown(ctx) }